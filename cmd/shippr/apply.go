@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/config"
+	"git-shippr/internal/dryrun"
+	"git-shippr/internal/gh"
+	"git-shippr/internal/policy"
+	"git-shippr/internal/query"
+)
+
+func applyCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	var org, repo, policyPath string
+	var repoLimit int
+	var explain bool
+	fs.StringVar(&org, "org", "", "GitHub organization to scan")
+	fs.StringVar(&repo, "repo", "", "Only this repository of --org")
+	fs.IntVar(&repoLimit, "repo-limit", 100, "Maximum repositories to scan")
+	fs.StringVar(&policyPath, "policy", "", "Policy file (default: $SHIPPR_POLICY or policy.json next to the config)")
+	fs.BoolVar(&explain, "explain", false, "Also list the rules each PR didn't match, and why")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr apply --org <org> [--repo <repo>] [--policy policy.json] [--explain] [--dry-run]")
+		fmt.Fprintln(os.Stderr, "       shippr apply [flags] <org/repo>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	repo, ok := scanTarget(fs, org, repo)
+	if !ok {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if policyPath == "" {
+		p, err := config.PolicyPath()
+		if err != nil {
+			fatal(err)
+		}
+		policyPath = p
+	}
+	pol, err := policy.Load(policyPath)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	prs, err := listOpenPRs(ctx, org, repo, repoLimit)
+	if err != nil {
+		fatal(err)
+	}
+	me, err := gh.CurrentUser(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	env := query.Env{Me: me, Now: time.Now()}
+
+	var acted, unmatched int
+	var failed []string
+	for _, r := range prs {
+		if err := ctx.Err(); err != nil {
+			fatal(err)
+		}
+		pr := policy.PR{RepoPR: r}
+		name := fmt.Sprintf("%s#%d", r.Repo, r.PR.Number)
+		if pol.NeedsDetails(pr, env) {
+			d, err := gh.GetPRDetails(ctx, r.Repo, r.PR.Number)
+			if err != nil {
+				failed = append(failed, name)
+				fmt.Println(errorStyle.Render(fmt.Sprintf("✗ %s: %s", name, gh.Friendly(err))))
+				continue
+			}
+			pr.Details = d
+		}
+		matches := pol.Evaluate(pr, env)
+		if !printMatches(pr, matches, explain) {
+			unmatched++
+			continue
+		}
+		if err := applyRules(ctx, pr, matches, me); err != nil {
+			failed = append(failed, name)
+			fmt.Println(errorStyle.Render("  ✗ " + gh.Friendly(err)))
+			continue
+		}
+		acted++
+	}
+
+	fmt.Println()
+	reportDryRun()
+	verb := "Applied"
+	if dryrun.Enabled() {
+		verb = "Would apply"
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("%s rules to %d PR(s); %d matched no rule", verb, acted, unmatched)))
+	if len(failed) > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d PR(s) failed: %s", len(failed), strings.Join(failed, ", "))))
+		os.Exit(exitError)
+	}
+}
+
+// printMatches shows the rules pr matched, and with explain the ones it
+// didn't along with the conditions it fails. It reports whether any rule
+// matched; PRs matching none are only printed with explain.
+func printMatches(pr policy.PR, matches []policy.Match, explain bool) bool {
+	matched := false
+	for _, m := range matches {
+		matched = matched || m.Matched()
+	}
+	if !matched && !explain {
+		return false
+	}
+	fmt.Printf("%s %s %s\n", branchStyle.Render(pr.Repo), prNumberStyle.Render(fmt.Sprintf("#%d", pr.PR.Number)), pr.PR.Title)
+	for _, m := range matches {
+		switch {
+		case m.Matched():
+			fmt.Println(successStyle.Render(fmt.Sprintf("  ✓ %s → %s", m.Rule.Name, m.Rule.Then)))
+		case explain:
+			fmt.Println(infoStyle.Render(fmt.Sprintf("  – %s: not %s", m.Rule.Name, strings.Join(m.Failed, ", "))))
+		}
+	}
+	return matched
+}
+
+// applyRules carries out the matched rules' actions in rule order. Labels
+// already on the PR and approvals it doesn't need are skipped, and a merge
+// or close ends the run.
+func applyRules(ctx context.Context, pr policy.PR, matches []policy.Match, me string) error {
+	labels := map[string]bool{}
+	for _, l := range pr.PR.Labels {
+		labels[strings.ToLower(l.Name)] = true
+	}
+	// GitHub doesn't let authors approve their own PRs.
+	approved := pr.PR.ReviewDecision == "APPROVED" || strings.EqualFold(pr.PR.Author.Login, me)
+	for _, m := range matches {
+		if !m.Matched() {
+			continue
+		}
+		a := m.Rule.Then
+		var add []string
+		for _, l := range a.Label {
+			if !labels[strings.ToLower(l)] {
+				labels[strings.ToLower(l)] = true
+				add = append(add, l)
+			}
+		}
+		if len(add) > 0 {
+			if err := gh.AddLabels(ctx, pr.Repo, pr.PR.Number, add...); err != nil {
+				return err
+			}
+		}
+		if a.Approve && !approved {
+			if err := gh.ApprovePR(ctx, pr.Repo, pr.PR.Number); err != nil {
+				return err
+			}
+			approved = true
+		}
+		switch {
+		case a.Merge != "":
+			return gh.MergePR(ctx, pr.Repo, pr.PR.Number, "--"+a.Merge, a.DeleteBranch)
+		case a.Close:
+			return gh.ClosePR(ctx, pr.Repo, pr.PR.Number)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"git-shippr/internal/bots"
+	"git-shippr/internal/dryrun"
+	"git-shippr/internal/gh"
+)
+
+func botsCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("bots", flag.ExitOnError)
+	var org, repo, maxUpdate, strategy string
+	var repoLimit int
+	var yes, deleteBranch bool
+	fs.StringVar(&org, "org", "", "GitHub organization to scan")
+	fs.StringVar(&repo, "repo", "", "Only this repository of --org")
+	fs.IntVar(&repoLimit, "repo-limit", 100, "Maximum repositories to scan")
+	fs.StringVar(&maxUpdate, "max", "minor", "Largest update to merge: patch, minor or major (major also merges unrecognised titles)")
+	fs.StringVar(&strategy, "strategy", "squash", "Merge strategy: squash, rebase or merge")
+	fs.BoolVar(&deleteBranch, "delete-branch", true, "Delete head branches after merging")
+	fs.BoolVar(&yes, "yes", false, "Approve and merge every eligible group without asking")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr bots --org <org> [--repo <repo>] [--max patch|minor|major] [--strategy squash] [--yes]")
+		fmt.Fprintln(os.Stderr, "       shippr bots [flags] <org/repo>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	repo, ok := scanTarget(fs, org, repo)
+	if !ok {
+		fs.Usage()
+		os.Exit(1)
+	}
+	limit, ok := bots.ParseUpdate(maxUpdate)
+	if !ok {
+		fatal(fmt.Errorf("invalid --max %q: want patch, minor or major", maxUpdate))
+	}
+	if limit == bots.Major {
+		limit = bots.Unknown
+	}
+	strategy, err := parseStrategy(strategy)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	prs, err := listOpenPRs(ctx, org, repo, repoLimit)
+	if err != nil {
+		fatal(err)
+	}
+	// Renovate titles only give the new version; the body tells patch from
+	// minor, the same way policy rules classify updates.
+	groups := bots.Collect(prs, func(r gh.RepoPR) string {
+		d, err := gh.GetPRDetails(ctx, r.Repo, r.PR.Number)
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("✗ %s#%d: %s; classifying it by title", r.Repo, r.PR.Number, gh.Friendly(err))))
+			return ""
+		}
+		return d.Body
+	})
+	if len(groups) == 0 {
+		fmt.Println(infoStyle.Render("No open PRs from bots"))
+		return
+	}
+
+	var merged, skipped int
+	var failed []string
+	mergedVerb, summaryVerb := "merged", "Merged"
+	if dryrun.Enabled() {
+		mergedVerb, summaryVerb = "would merge", "Would merge"
+	}
+	for _, g := range groups {
+		ready := printBotGroup(g)
+		switch {
+		case g.Update > limit:
+			fmt.Println(infoStyle.Render(fmt.Sprintf("  skipped: %s updates need --max %s", g.Update, bots.Major)) + "\n")
+			skipped++
+			continue
+		case len(ready) == 0:
+			fmt.Println(infoStyle.Render("  skipped: no PR is ready to merge") + "\n")
+			skipped++
+			continue
+		}
+		prompt := fmt.Sprintf("  Approve and merge %d PR(s) updating %s (%s)? (y/N) ", len(ready), g.Dependency, g.Update)
+		if !yes && !confirm(prompt) {
+			fmt.Println()
+			skipped++
+			continue
+		}
+		for _, p := range ready {
+			if err := ctx.Err(); err != nil {
+				fatal(err)
+			}
+			name := fmt.Sprintf("%s#%d", p.Repo, p.PR.Number)
+			err := gh.ApprovePR(ctx, p.Repo, p.PR.Number)
+			if err == nil {
+				err = gh.MergePR(ctx, p.Repo, p.PR.Number, strategy, deleteBranch)
+			}
+			if err != nil {
+				failed = append(failed, name)
+				fmt.Println(errorStyle.Render(fmt.Sprintf("  ✗ %s: %s", name, gh.Friendly(err))))
+				continue
+			}
+			merged++
+			fmt.Println(successStyle.Render("  ✓ " + mergedVerb + " " + name))
+		}
+		fmt.Println()
+	}
+
+	reportDryRun()
+	fmt.Println(successStyle.Render(fmt.Sprintf("%s %d bot PR(s); %d group(s) skipped", summaryVerb, merged, skipped)))
+	if len(failed) > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d PR(s) failed: %s", len(failed), strings.Join(failed, ", "))))
+		os.Exit(exitError)
+	}
+}
+
+// printBotGroup lists a group's PRs with whether each is ready to merge and
+// returns the ready ones.
+func printBotGroup(g bots.Group) []bots.PR {
+	fmt.Printf("%s %s %s\n", titleStyle.Render(g.Dependency), botUpdateLabel(g.Update),
+		infoStyle.Render(fmt.Sprintf("%d PR(s)", len(g.PRs))))
+	var ready []bots.PR
+	for _, p := range g.PRs {
+		version := p.Bump.To
+		if p.Bump.From != "" {
+			version = p.Bump.From + " → " + p.Bump.To
+		}
+		status := successStyle.Render("ready")
+		if ok, reason := bots.Ready(p.PR); ok {
+			ready = append(ready, p)
+		} else {
+			status = accentStyle.Render(reason)
+		}
+		fmt.Printf("  %s %s %s %s\n", branchStyle.Render(p.Repo), prNumberStyle.Render(fmt.Sprintf("#%d", p.PR.Number)),
+			infoStyle.Render(version), status)
+	}
+	return ready
+}
+
+func botUpdateLabel(u bots.Update) string {
+	switch u {
+	case bots.Patch, bots.Minor:
+		return successStyle.Render(u.String())
+	case bots.Major:
+		return errorStyle.Render(u.String())
+	}
+	return accentStyle.Render(u.String())
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"git-shippr/internal/dryrun"
+)
+
+// dryRunBanner is shown above every TUI screen in dry-run mode, with the
+// most recent command that was recorded instead of sent.
+func dryRunBanner() string {
+	if !dryrun.Enabled() {
+		return ""
+	}
+	banner := errorStyle.Bold(true).Reverse(true).Render(" DRY RUN ") + " " +
+		infoStyle.Render("nothing is sent to GitHub")
+	if calls := dryrun.Calls(); len(calls) > 0 {
+		banner += "\n" + infoStyle.Render("would run: "+truncate(calls[len(calls)-1], 120))
+	}
+	return banner + "\n\n"
+}
+
+// reportDryRun lists every command a dry run recorded once the TUI exits.
+func reportDryRun() {
+	if !dryrun.Enabled() {
+		return
+	}
+	calls := dryrun.Calls()
+	fmt.Println(accentStyle.Render(fmt.Sprintf("DRY RUN: %d command(s) would have been sent", len(calls))))
+	for _, c := range calls {
+		fmt.Println("  " + c)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/audit"
+	"git-shippr/internal/query"
+)
+
+func historyCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var f audit.Filter
+	var since, until string
+	var asJSON bool
+	fs.StringVar(&f.Repo, "repo", "", "Only actions on this repository (org/repo)")
+	fs.IntVar(&f.PR, "pr", 0, "Only actions on this PR number")
+	fs.StringVar(&f.Action, "action", "", "Only this action: merge, approve, request-changes, label, comment, ...")
+	fs.StringVar(&since, "since", "", "Only actions after this date (2026-01-31) or age (7d, 2w)")
+	fs.StringVar(&until, "until", "", "Only actions before this date (2026-01-31) or age (7d, 2w)")
+	fs.BoolVar(&asJSON, "json", false, "Print matching entries as JSON lines")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr history [--repo org/repo] [--pr N] [--action merge] [--since 7d] [--until 2026-01-31]")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	cfg, err := opts.setup()
+	if err != nil {
+		fatal(err)
+	}
+	now := time.Now()
+	if f.Since, err = parseHistoryTime(since, now); err != nil {
+		fatal(err)
+	}
+	if f.Until, err = parseHistoryTime(until, now); err != nil {
+		fatal(err)
+	}
+	path, err := cfg.AuditLogPath()
+	if err != nil {
+		fatal(err)
+	}
+	if path == "" {
+		fatal(fmt.Errorf("the audit log is disabled (audit_log is \"off\" in the config)"))
+	}
+	entries, err := audit.Read(path, f)
+	if err != nil {
+		fatal(err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			_ = enc.Encode(e)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println(infoStyle.Render("No matching actions in " + path))
+		return
+	}
+	for _, e := range entries {
+		fmt.Println(formatHistoryEntry(e))
+	}
+}
+
+// parseHistoryTime accepts a date, an RFC 3339 timestamp or an age relative
+// to now such as "7d". Empty means no bound.
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := query.ParseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use a date (2026-01-31), a timestamp or an age (7d)", s)
+	}
+	return now.Add(-d), nil
+}
+
+func formatHistoryEntry(e audit.Entry) string {
+	outcome := successStyle.Render("✓")
+	switch e.Outcome {
+	case audit.Success:
+	case audit.Cancelled:
+		outcome = accentStyle.Render("⊘")
+	default:
+		outcome = errorStyle.Render("✗")
+	}
+	target := e.Repo
+	if e.PR > 0 {
+		target += prNumberStyle.Render(fmt.Sprintf("#%d", e.PR))
+	}
+	parts := []string{
+		infoStyle.Render(e.Time.Local().Format("2006-01-02 15:04")),
+		outcome,
+		accentStyle.Render(padRight(e.Action, 15)),
+		target,
+	}
+	var details []string
+	if e.Actor != "" {
+		details = append(details, "by @"+e.Actor)
+	}
+	if e.Strategy != "" {
+		details = append(details, strings.TrimPrefix(e.Strategy, "--"))
+	}
+	if e.DeleteBranch {
+		details = append(details, "deleted branch")
+	}
+	if e.Branch != "" {
+		details = append(details, "branch "+e.Branch)
+	}
+	if e.HeadSHA != "" {
+		details = append(details, "head "+shortSHA(e.HeadSHA))
+	}
+	if e.Detail != "" {
+		details = append(details, truncate(e.Detail, 60))
+	}
+	line := strings.Join(parts, " ")
+	if len(details) > 0 {
+		line += " " + infoStyle.Render("("+strings.Join(details, ", ")+")")
+	}
+	if e.Error != "" {
+		line += "\n    " + errorStyle.Render(truncate(strings.ReplaceAll(e.Error, "\n", " "), 200))
+	}
+	return line
+}
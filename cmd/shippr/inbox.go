@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+// inboxSource lists PRs across all repositories that wait on the current
+// user: review requests, assignments and mentions.
+func inboxSource(limit int) prSource {
+	return prSource{
+		title:   "Inbox · Needs my attention",
+		empty:   "Inbox zero: nothing is waiting on you",
+		multi:   true,
+		timeout: 45 * time.Second,
+		fetch: func(ctx context.Context, _ gh.ListOptions) ([]gh.RepoPR, error) {
+			return gh.Inbox(ctx, limit)
+		},
+	}
+}
+
+func inboxCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("inbox", flag.ExitOnError)
+	var limit int
+	var noAlt bool
+	fs.IntVar(&limit, "limit", 100, "Maximum results per search (review requested, assigned, mentioned)")
+	fs.BoolVar(&noAlt, "no-alt", false, "Disable alternate screen (render in normal screen)")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr inbox [--limit N]")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	cfg, err := opts.setup()
+	if err != nil {
+		fatal(err)
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	if err := runTUI(initialModel(ctx, inboxSource(limit), cfg), noAlt); err != nil {
+		fatal(err)
+	}
+}
@@ -54,6 +54,6 @@ var logoLines = []string{
 }
 
 func getLogo() string {
-	logoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#C471ED"))
+	logoStyle := lipgloss.NewStyle().Foreground(primary)
 	return logoStyle.Render(strings.Join(logoLines, "\n"))
 }
\ No newline at end of file
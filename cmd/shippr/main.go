@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"git-shippr/internal/audit"
+	"git-shippr/internal/config"
+	"git-shippr/internal/dryrun"
 	"git-shippr/internal/gh"
+	"git-shippr/internal/gitx"
 
 	list "github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -26,51 +35,16 @@ const (
 	stageConfirmDelete
 	stageMerging
 	stageDone
+	stageOfferUpdate
+	stageRebase
+	stageConfirmStack
+	stageMergingStack
+	stageReverting
+	stageConfirmRevertMerge
 )
 
-var (
-	primary   = lipgloss.Color("#C471ED")
-	secondary = lipgloss.Color("#DA70D6")
-	accent    = lipgloss.Color("#DDA0DD")
-	dark      = lipgloss.Color("#8B008B")
-	light     = lipgloss.Color("#E6E6FA")
-
-	titleStyle = lipgloss.NewStyle().
-			Foreground(primary).
-			Bold(true)
-
-	infoStyle = lipgloss.NewStyle().
-			Foreground(light)
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(secondary).
-			Bold(true)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF1493")).
-			Bold(true)
-
-	branchStyle = lipgloss.NewStyle().
-			Foreground(accent)
-
-	accentStyle = lipgloss.NewStyle().
-			Foreground(accent)
-
-	prNumberStyle = lipgloss.NewStyle().
-			Foreground(primary).
-			Bold(true)
-
-	highlightStyle = lipgloss.NewStyle().
-			Foreground(dark).
-			Background(light).
-			Bold(true).
-			Padding(0, 1)
-
-	borderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(primary).
-			Padding(1)
-)
+// prPageSize is how many more PRs "load more" fetches.
+const prPageSize = 30
 
 const (
 	mergeSquash = "--squash"
@@ -78,46 +52,202 @@ const (
 	mergeMerge  = "--merge"
 )
 
-type prItem struct{ gh.PR }
+// parseStrategy checks a --strategy value, given with or without dashes,
+// and returns its gh pr merge flag.
+func parseStrategy(s string) (string, error) {
+	switch flag := "--" + strings.TrimPrefix(s, "--"); flag {
+	case mergeSquash, mergeRebase, mergeMerge:
+		return flag, nil
+	}
+	return "", fmt.Errorf("invalid --strategy %q: want squash, rebase or merge", s)
+}
+
+type prItem struct {
+	gh.PR
+	repo       string
+	reasons    []string
+	showRepo   bool
+	staleAfter time.Duration
+	tree       string
+	queuePos   int
+}
 
 func (i prItem) Title() string {
-	return fmt.Sprintf("%s %s",
+	title := fmt.Sprintf("%s %s",
 		prNumberStyle.Render(fmt.Sprintf("#%d", i.Number)),
 		i.PR.Title)
+	if i.showRepo {
+		title = accentStyle.Render(i.repo) + " " + title
+	}
+	if i.tree != "" {
+		title = infoStyle.Render(i.tree) + title
+	}
+	return title
 }
 
 func (i prItem) Description() string {
-	return fmt.Sprintf("Branch: %s", branchStyle.Render(i.HeadRefName))
+	now := time.Now()
+	desc := prRowDescription(i.PR, now)
+	if i.staleAfter > 0 {
+		if t, err := time.Parse(time.RFC3339, i.UpdatedAt); err == nil && now.Sub(t) > i.staleAfter {
+			desc = errorStyle.Render("⏳ stale "+relativeAge(i.UpdatedAt, now)) + "  " + desc
+		}
+	}
+	if len(i.reasons) > 0 {
+		desc = accentStyle.Render(strings.Join(i.reasons, ", ")) + "  " + desc
+	}
+	if i.queuePos > 0 {
+		desc = accentStyle.Render(fmt.Sprintf("⧗ queued #%d", i.queuePos)) + "  " + desc
+	}
+	return desc
 }
 
 func (i prItem) FilterValue() string {
-	return fmt.Sprintf("%d %s %s", i.Number, i.PR.Title, i.HeadRefName)
+	return fmt.Sprintf("%s#%d %s %s", i.repo, i.Number, i.PR.Title, i.HeadRefName)
 }
 
+// loadMoreItem is the last row of a truncated listing.
+type loadMoreItem struct{ shown int }
+
+func (i loadMoreItem) Title() string { return accentStyle.Render("↓ Load more") }
+func (i loadMoreItem) Description() string {
+	return infoStyle.Render(fmt.Sprintf("%d shown; enter or ] fetches %d more", i.shown, prPageSize))
+}
+func (i loadMoreItem) FilterValue() string { return "" }
+
 type strategyItem struct{ flag, label string }
 
 func (s strategyItem) Title() string       { return s.label }
 func (s strategyItem) Description() string { return s.flag }
 func (s strategyItem) FilterValue() string { return s.label }
 
+// prSource describes what the picker lists: a single repository or a
+// cross-repository search such as the inbox.
+type prSource struct {
+	title   string
+	empty   string
+	multi   bool
+	timeout time.Duration
+	// staleAfter flags rows not updated within this duration (0 = never).
+	staleAfter time.Duration
+	// states enables the open/merged/closed/all selector and paging; fetch
+	// then receives the selected state and a limit covering the page.
+	states bool
+	// limit is the initial number of PRs for sources with states.
+	limit int
+	fetch func(ctx context.Context, opts gh.ListOptions) ([]gh.RepoPR, error)
+	// cached, if set, returns the last fetch result from the response cache
+	// so the picker can render it while fetch revalidates.
+	cached func(opts gh.ListOptions) ([]gh.RepoPR, time.Time, bool)
+	// stream, if set, replaces fetch for sources that produce results
+	// piecemeal; rows are added to the picker as each batch arrives.
+	stream func(ctx context.Context, opts gh.ListOptions, onBatch func(gh.OrgBatch)) (*gh.OrgPRs, error)
+}
+
+func repoSource(repo string, limit int) prSource {
+	return prSource{
+		title:   "Pull Requests",
+		empty:   fmt.Sprintf("No open pull requests found for %s", titleStyle.Render(repo)),
+		timeout: 15 * time.Second,
+		states:  true,
+		limit:   limit,
+		fetch: func(ctx context.Context, opts gh.ListOptions) ([]gh.RepoPR, error) {
+			prs, err := gh.ListPRs(ctx, repo, opts)
+			if err != nil {
+				return nil, err
+			}
+			return repoRows(repo, prs), nil
+		},
+		cached: func(opts gh.ListOptions) ([]gh.RepoPR, time.Time, bool) {
+			prs, at, ok := gh.CachedPRs(repo, opts)
+			return repoRows(repo, prs), at, ok
+		},
+	}
+}
+
+func repoRows(repo string, prs []gh.PR) []gh.RepoPR {
+	rows := make([]gh.RepoPR, 0, len(prs))
+	for _, p := range prs {
+		rows = append(rows, gh.RepoPR{Repo: repo, PR: p})
+	}
+	return rows
+}
+
+// fingerprint summarises rows so a background refresh that changed nothing
+// doesn't disturb the picker.
+func fingerprint(rows []gh.RepoPR) string {
+	prs := make([]gh.PR, 0, len(rows))
+	for _, r := range rows {
+		prs = append(prs, r.PR)
+	}
+	return gh.Fingerprint(prs)
+}
+
 type model struct {
 	ctx       context.Context
-	repo      string
-	prs       []gh.PR
+	source    prSource
+	me        string
+	prs       []gh.RepoPR
 	list      list.Model
+	filter    *prFilter
+	views     []config.View
+	view      int
 	spinner   spinner.Model
 	stage     int
 	selected  *gh.PR
+	selRepo   string
 	prDetails *gh.PRDetails
-	strat     string
-	deleteBr  bool
-	status    string
-	err       error
+	// mergeQueue is nil when the queue lookup failed (e.g. older GHES).
+	mergeQueue *gh.MergeQueueStatus
+	notice     string
+	checkout   *gitx.Checkout
+	conflicts  []string
+	// emptyCommit is set while the rebase is stopped at a commit with
+	// nothing left to commit.
+	emptyCommit bool
+	stack       *stackMerge
+	revert      *revertResult
+	state       string
+	// limit is how many PRs to fetch; "load more" raises it.
+	limit int
+	more  bool
+	// keepIndex restores the cursor after loading more.
+	keepIndex int
+	// merged is set after a successful merge so it can be reverted.
+	merged bool
+	// cachedAt/detailsCachedAt are set while cached data is shown and a
+	// refresh is in flight.
+	cachedAt        time.Time
+	detailsCachedAt time.Time
+	// quota is the API quota as of the last fetch, shown in the footer.
+	quota *gh.RateLimits
+	scan  scanProgress
+	// fetchCtx and detailsCtx bound the reads behind the picker and the
+	// summary; they are cancelled when the user moves on before gh answers.
+	fetchCtx    context.Context
+	stopFetch   context.CancelFunc
+	detailsCtx  context.Context
+	stopDetails context.CancelFunc
+	// quitting is set when the user quits mid-merge: the program exits
+	// once GitHub has answered, so the outcome is never left unknown.
+	quitting bool
+	strat    string
+	deleteBr bool
+	status   string
+	err      error
 }
 
 type fetchedMsg struct {
-	prs []gh.PR
-	err error
+	prs []gh.RepoPR
+	me  string
+	// more reports that the limit cut the listing short.
+	more bool
+	// cachedAt is set when the result came from the response cache.
+	cachedAt time.Time
+	quota    *gh.RateLimits
+	// warning reports rows that came back incomplete.
+	warning string
+	err     error
 }
 
 type mergedMsg struct{ err error }
@@ -125,46 +255,127 @@ type mergedMsg struct{ err error }
 type openInBrowserMsg struct{}
 
 type prDetailsMsg struct {
-	details *gh.PRDetails
-	err     error
+	details    *gh.PRDetails
+	mergeQueue *gh.MergeQueueStatus
+	cachedAt   time.Time
+	err        error
 }
 
 type reviewActionMsg struct{ err error }
 
-func initialModel(ctx context.Context, repo string) model {
-	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "Open Pull Requests"
+// prActionMsg reports a PR action that keeps the user on the summary
+// screen; done is shown as a notice on success.
+type prActionMsg struct {
+	done string
+	err  error
+}
+
+func initialModel(ctx context.Context, source prSource, cfg *config.Config) model {
+	f := newPRFilter()
+	l := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	l.Title = source.title
+	styleList(&l)
 	l.SetShowHelp(false)
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
+	l.Filter = f.filter
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(primary)
 
-	return model{
+	m := model{
 		ctx:     ctx,
-		repo:    repo,
+		source:  source,
 		list:    l,
+		filter:  f,
+		views:   pickerViews(cfg),
 		spinner: s,
 		stage:   stageFetch,
 		strat:   mergeSquash,
+		state:   gh.StateOpen,
+		limit:   source.limit,
 	}
+	m.bind(ctx)
+	return m
+}
+
+// bind makes ctx the parent of everything the model runs.
+func (m *model) bind(ctx context.Context) {
+	m.ctx = ctx
+	m.fetchCtx, m.stopFetch = context.WithCancel(ctx)
+	m.detailsCtx, m.stopDetails = context.WithCancel(ctx)
 }
 
 func (m model) Init() tea.Cmd {
 	return tea.Batch(m.fetchPRs(), m.spinner.Tick, tea.EnterAltScreen)
 }
 
+func (m model) listOptions() gh.ListOptions {
+	if !m.source.states {
+		return gh.ListOptions{}
+	}
+	return gh.ListOptions{State: m.state, Limit: m.limit}
+}
+
+// fetchPRs lists PRs from GitHub, preceded by the cached listing when there
+// is one so the picker shows up immediately.
 func (m model) fetchPRs() tea.Cmd {
-	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(m.ctx, 15*time.Second)
+	if m.source.stream != nil {
+		return m.streamPRs()
+	}
+	fetch := func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.fetchCtx, m.source.timeout)
 		defer cancel()
 		if err := gh.EnsureGH(ctx); err != nil {
 			return fetchedMsg{err: err}
 		}
-		prs, err := gh.ListPRs(ctx, m.repo)
-		return fetchedMsg{prs: prs, err: err}
+		opts := m.listOptions()
+		prs, err := m.source.fetch(ctx, opts)
+		var warning string
+		var partial *gh.EnrichError
+		if errors.As(err, &partial) {
+			warning = partial.Error()
+		} else if err != nil {
+			return fetchedMsg{err: err}
+		}
+		more := m.source.states && opts.Truncated(len(prs))
+		// "me" in queries is best effort; a failure here shouldn't block listing.
+		me, _ := gh.CurrentUser(ctx)
+		quota, _ := gh.GetRateLimit(ctx)
+		return fetchedMsg{prs: prs, me: me, more: more, quota: quota, warning: warning}
+	}
+	if m.source.cached == nil {
+		return fetch
+	}
+	cached := func() tea.Msg {
+		opts := m.listOptions()
+		prs, at, ok := m.source.cached(opts)
+		if !ok {
+			return nil
+		}
+		more := m.source.states && opts.Truncated(len(prs))
+		return fetchedMsg{prs: prs, more: more, cachedAt: at}
+	}
+	return tea.Batch(cached, fetch)
+}
+
+// waitingForDetails is shown when merging is asked for while only cached
+// details are on screen.
+const waitingForDetails = "Waiting for fresh PR details before merging..."
+
+// cachedPRDetails shows the selected PR's cached details, if any, while
+// fetchPRDetails runs.
+func (m model) cachedPRDetails() tea.Cmd {
+	return func() tea.Msg {
+		if m.selected == nil {
+			return nil
+		}
+		details, at, ok := gh.CachedPRDetails(m.selRepo, m.selected.Number)
+		if !ok {
+			return nil
+		}
+		return prDetailsMsg{details: details, cachedAt: at}
 	}
 }
 
@@ -173,10 +384,14 @@ func (m model) fetchPRDetails() tea.Cmd {
 		if m.selected == nil {
 			return prDetailsMsg{err: fmt.Errorf("no PR selected")}
 		}
-		ctx, cancel := context.WithTimeout(m.ctx, 15*time.Second)
+		ctx, cancel := context.WithTimeout(m.detailsCtx, 15*time.Second)
 		defer cancel()
-		details, err := gh.GetPRDetails(ctx, m.repo, m.selected.Number)
-		return prDetailsMsg{details: details, err: err}
+		details, err := gh.GetPRDetails(ctx, m.selRepo, m.selected.Number)
+		if err != nil {
+			return prDetailsMsg{err: err}
+		}
+		mq, _ := gh.GetMergeQueue(ctx, m.selRepo, m.selected.Number, details.BaseRefName)
+		return prDetailsMsg{details: details, mergeQueue: mq}
 	}
 }
 
@@ -187,7 +402,7 @@ func (m model) approvePR() tea.Cmd {
 		}
 		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
 		defer cancel()
-		err := gh.ApprovePR(ctx, m.repo, m.selected.Number)
+		err := gh.ApprovePR(ctx, m.selRepo, m.selected.Number)
 		return reviewActionMsg{err: err}
 	}
 }
@@ -199,11 +414,53 @@ func (m model) requestChanges() tea.Cmd {
 		}
 		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
 		defer cancel()
-		err := gh.RequestChanges(ctx, m.repo, m.selected.Number, "Changes requested via shippr")
+		err := gh.RequestChanges(ctx, m.selRepo, m.selected.Number, "Changes requested via shippr")
 		return reviewActionMsg{err: err}
 	}
 }
 
+func (m model) rerequestReview() tea.Cmd {
+	return func() tea.Msg {
+		if m.prDetails == nil {
+			return prActionMsg{err: fmt.Errorf("no PR selected")}
+		}
+		reviewers := gh.ReviewersToRerequest(m.prDetails)
+		if len(reviewers) == 0 {
+			return prActionMsg{done: "No previous reviewers to ask again"}
+		}
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
+		defer cancel()
+		err := gh.RequestReview(ctx, m.selRepo, m.prDetails.Number, reviewers)
+		return prActionMsg{done: "Review re-requested from " + strings.Join(reviewers, ", "), err: err}
+	}
+}
+
+func (m model) updateBranch(repo string, number int, rebase bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
+		defer cancel()
+		err := gh.UpdateBranch(ctx, repo, number, rebase)
+		done := fmt.Sprintf("#%d: merged latest base into branch; checks will re-run", number)
+		if rebase {
+			done = fmt.Sprintf("#%d: rebased branch onto latest base; checks will re-run", number)
+		}
+		return prActionMsg{done: done, err: err}
+	}
+}
+
+// setPRState closes the selected PR, or reopens it when reopen is set.
+func (m model) setPRState(reopen bool) tea.Cmd {
+	repo, number := m.selRepo, m.selected.Number
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
+		defer cancel()
+		if reopen {
+			return prActionMsg{done: fmt.Sprintf("#%d reopened", number), err: gh.ReopenPR(ctx, repo, number)}
+		}
+		return prActionMsg{done: fmt.Sprintf("#%d closed", number), err: gh.ClosePR(ctx, repo, number)}
+	}
+}
+
 func (m model) mergeSelected() tea.Cmd {
 	return func() tea.Msg {
 		if m.selected == nil {
@@ -211,7 +468,7 @@ func (m model) mergeSelected() tea.Cmd {
 		}
 		ctx, cancel := context.WithTimeout(m.ctx, 60*time.Second)
 		defer cancel()
-		err := gh.MergePR(ctx, m.repo, m.selected.Number, m.strat, m.deleteBr)
+		err := gh.MergePR(ctx, m.selRepo, m.selected.Number, m.strat, m.deleteBr)
 		return mergedMsg{err: err}
 	}
 }
@@ -219,7 +476,9 @@ func (m model) mergeSelected() tea.Cmd {
 func (m model) openSelectedInBrowser() tea.Cmd {
 	return func() tea.Msg {
 		if m.selected != nil {
-			_ = gh.ViewPRWeb(m.ctx, m.repo, m.selected.Number)
+			ctx, cancel := context.WithTimeout(m.ctx, 15*time.Second)
+			defer cancel()
+			_ = gh.ViewPRWeb(ctx, m.selRepo, m.selected.Number)
 		}
 		return openInBrowserMsg{}
 	}
@@ -228,7 +487,13 @@ func (m model) openSelectedInBrowser() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.list.SetSize(msg.Width, msg.Height-2)
+		// Leave room for the notice and API quota lines.
+		height := msg.Height - 3
+		if dryrun.Enabled() {
+			// Room for the banner and the last recorded command.
+			height -= 4
+		}
+		m.list.SetSize(msg.Width, height)
 		return m, nil
 
 	case spinner.TickMsg:
@@ -237,41 +502,93 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case fetchedMsg:
+		if !msg.cachedAt.IsZero() && m.stage != stageFetch {
+			// The network result won the race; it is newer.
+			return m, nil
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			// Superseded by a refetch.
+			return m, nil
+		}
+		if msg.cachedAt.IsZero() && m.stage != stageFetch {
+			return m.revalidated(msg), nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
-			m.status = fmt.Sprintf("Failed to fetch PRs: %v", msg.err)
+			m.status = fmt.Sprintf("Failed to fetch PRs: %s", gh.Friendly(msg.err))
 			m.stage = stageDone
 			return m, nil
 		}
 		m.prs = msg.prs
-		if len(m.prs) == 0 {
-			m.status = fmt.Sprintf("No open pull requests found for %s", titleStyle.Render(m.repo))
+		m.more = msg.more
+		m.cachedAt = msg.cachedAt
+		if msg.quota != nil {
+			m.quota = msg.quota
+		}
+		if len(m.prs) == 0 && m.state == gh.StateOpen && m.cachedAt.IsZero() {
+			m.status = m.source.empty
 			m.stage = stageDone
 			return m, nil
 		}
-		items := make([]list.Item, 0, len(m.prs))
-		for _, p := range m.prs {
-			items = append(items, prItem{PR: p})
+		if len(m.prs) == 0 {
+			m.notice = fmt.Sprintf("No %s pull requests", m.state)
+		}
+		if msg.warning != "" {
+			m.notice = msg.warning
 		}
-		m.list.SetItems(items)
+		m.me = msg.me
+		m.filter.setMe(msg.me)
+		m.setPRItems()
+		m.list.Select(m.keepIndex)
+		m.keepIndex = 0
 		m.stage = stagePickPR
 		return m, nil
 
+	case orgBatchMsg:
+		return m.handleOrgBatch(msg)
+
+	case orgDoneMsg:
+		return m.handleOrgDone(msg)
+
 	case prDetailsMsg:
+		if !msg.cachedAt.IsZero() && m.prDetails != nil || errors.Is(msg.err, context.Canceled) || m.detailsCtx.Err() != nil {
+			// Stale cache, or the user went back before gh answered.
+			return m, nil
+		}
+		if msg.err != nil && !m.detailsCachedAt.IsZero() {
+			m.notice = fmt.Sprintf("Refresh failed, showing cached details: %s", gh.Friendly(msg.err))
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
-			m.status = fmt.Sprintf("Failed to fetch PR details: %v", msg.err)
+			m.status = fmt.Sprintf("Failed to fetch PR details: %s", gh.Friendly(msg.err))
 			m.stage = stageDone
 			return m, nil
 		}
 		m.prDetails = msg.details
-		m.stage = stageViewSummary
+		m.detailsCachedAt = msg.cachedAt
+		if msg.cachedAt.IsZero() {
+			m.mergeQueue = msg.mergeQueue
+			if m.notice == waitingForDetails {
+				m.notice = ""
+			}
+		}
+		if m.selected != nil && m.selected.HeadRefName == "" {
+			// Search results (inbox) don't include branch names.
+			m.selected.HeadRefName = msg.details.HeadRefName
+		}
+		// Show the summary while the user waits for it or looks at it; a
+		// refresh landing mid-prompt or mid-merge only updates the data.
+		switch m.stage {
+		case stagePickPR, stageFetch, stageViewSummary:
+			m.stage = stageViewSummary
+		}
 		return m, nil
 
 	case reviewActionMsg:
 		if msg.err != nil {
 			m.err = msg.err
-			m.status = fmt.Sprintf("Review action failed: %v", msg.err)
+			m.status = fmt.Sprintf("Review action failed: %s", gh.Friendly(msg.err))
 			m.stage = stageDone
 			return m, nil
 		}
@@ -279,19 +596,106 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.stage = stageConfirmOpen
 		return m, nil
 
+	case rebaseMsg:
+		return m.handleRebase(msg)
+
+	case stackStepMsg:
+		return m.handleStackStep(msg)
+
+	case revertMsg:
+		return m.handleRevert(msg)
+
+	case prActionMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = fmt.Sprintf("Action failed: %s", gh.Friendly(msg.err))
+			m.stage = stageDone
+			return m, nil
+		}
+		m.notice = msg.done
+		if m.stage == stagePickPR {
+			return m, nil
+		}
+		return m, m.fetchPRDetails()
+
 	case tea.KeyMsg:
 		switch m.stage {
+		case stageFetch:
+			switch msg.String() {
+			case "q", "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		case stageMerging, stageMergingStack, stageReverting:
+			switch msg.String() {
+			case "q", "esc", "ctrl+c":
+				// Killing gh now could leave the merge half done; wait
+				// for GitHub's answer and quit then.
+				m.quitting = true
+				m.notice = "Quitting once GitHub confirms the current step..."
+			}
+			return m, nil
 		case stagePickPR:
+			if m.list.SettingFilter() {
+				break
+			}
 			switch msg.String() {
+			case "v":
+				m.cycleView(1)
+				return m, nil
+			case "V":
+				m.cycleView(-1)
+				return m, nil
 			case "enter":
+				if _, ok := m.list.SelectedItem().(loadMoreItem); ok {
+					return m, m.loadMore()
+				}
 				if it, ok := m.list.SelectedItem().(prItem); ok {
 					p := it.PR
 					m.selected = &p
+					m.selRepo = it.repo
+					m.prDetails = nil
+					m.mergeQueue = nil
+					m.notice = ""
 					m.status = "Fetching PR details..."
-					return m, m.fetchPRDetails()
+					m.restartDetails()
+					return m, tea.Batch(m.cachedPRDetails(), m.fetchPRDetails())
 				}
 				return m, nil
-			case "q", "esc", "ctrl+c":
+			case "s":
+				if m.source.states {
+					m.cycleState()
+					return m, m.refetch()
+				}
+				return m, nil
+			case "]":
+				if m.more {
+					return m, m.loadMore()
+				}
+				return m, nil
+			case "S":
+				if it, ok := m.list.SelectedItem().(prItem); ok {
+					return m.confirmStack(it)
+				}
+				return m, nil
+			case "Q":
+				if it, ok := m.list.SelectedItem().(prItem); ok && !m.source.multi {
+					m.toggleQueue(it)
+				}
+				return m, nil
+			case "u":
+				if it, ok := m.list.SelectedItem().(prItem); ok {
+					m.notice = fmt.Sprintf("Updating branch of #%d...", it.Number)
+					return m, m.updateBranch(it.repo, it.Number, false)
+				}
+				return m, nil
+			case "esc":
+				if m.list.IsFiltered() {
+					m.view = 0
+					m.applyView()
+					return m, nil
+				}
+				return m, tea.Quit
+			case "q", "ctrl+c":
 				return m, tea.Quit
 			}
 		case stageViewSummary:
@@ -302,10 +706,63 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "r":
 				m.status = "Requesting changes..."
 				return m, m.requestChanges()
+			case "R":
+				m.notice = "Re-requesting review..."
+				return m, m.rerequestReview()
+			case "u", "U":
+				m.notice = "Updating branch..."
+				return m, m.updateBranch(m.selRepo, m.selected.Number, msg.String() == "U")
+			case "L":
+				m.notice = "Preparing local checkout for rebase..."
+				return m, m.startRebase()
 			case "m", "enter":
+				if !m.detailsCachedAt.IsZero() {
+					// Cached details may predate failing checks or a
+					// merge queue requirement.
+					m.notice = waitingForDetails
+					return m, nil
+				}
+				if m.usesMergeQueue() {
+					if m.mergeQueue.InQueue {
+						m.notice = fmt.Sprintf("Already in the merge queue at position %d; press D to remove it", m.mergeQueue.Position)
+						return m, nil
+					}
+					m.notice = "Adding to merge queue..."
+					return m, m.enqueueSelected()
+				}
+				if needsUpdate(m.prDetails) {
+					m.stage = stageOfferUpdate
+					return m, nil
+				}
 				m.stage = stageConfirmOpen
 				return m, nil
+			case "c":
+				if m.prDetails.State == "OPEN" {
+					m.notice = "Closing PR..."
+					return m, m.setPRState(false)
+				}
+				return m, nil
+			case "o":
+				if m.prDetails.State == "CLOSED" {
+					m.notice = "Reopening PR..."
+					return m, m.setPRState(true)
+				}
+				return m, nil
+			case "X":
+				if m.prDetails.State == "MERGED" {
+					m.status = fmt.Sprintf("Reverting #%d...", m.selected.Number)
+					m.stage = stageReverting
+					return m, m.startRevert()
+				}
+				return m, nil
+			case "D":
+				if m.usesMergeQueue() && m.mergeQueue.InQueue {
+					m.notice = "Removing from merge queue..."
+					return m, m.dequeueSelected()
+				}
+				return m, nil
 			case "b", "esc":
+				m.stopDetails()
 				m.stage = stagePickPR
 				return m, nil
 			case "q", "ctrl+c":
@@ -318,7 +775,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.openSelectedInBrowser()
 			case "n", "N", "enter":
 				m.stage = stagePickStrategy
-				return m, m.showStrategies()
+				m.showStrategies()
+				return m, nil
 			case "q", "esc", "ctrl+c":
 				return m, tea.Quit
 			}
@@ -348,26 +806,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "q", "esc", "ctrl+c":
 				return m, tea.Quit
 			}
+		case stageOfferUpdate:
+			switch msg.String() {
+			case "u", "U":
+				m.stage = stageViewSummary
+				m.notice = "Updating branch..."
+				return m, m.updateBranch(m.selRepo, m.selected.Number, msg.String() == "U")
+			case "L":
+				m.stage = stageViewSummary
+				m.notice = "Preparing local checkout for rebase..."
+				return m, m.startRebase()
+			case "n", "N", "enter":
+				m.stage = stageConfirmOpen
+				return m, nil
+			case "b", "esc":
+				m.stage = stageViewSummary
+				return m, nil
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			}
+		case stageRebase:
+			return m.updateRebase(msg)
+		case stageConfirmStack:
+			return m.updateConfirmStack(msg)
+		case stageConfirmRevertMerge:
+			return m.updateConfirmRevertMerge(msg)
+		case stageDone:
+			switch msg.String() {
+			case "X":
+				if m.merged {
+					m.status = fmt.Sprintf("Reverting #%d...", m.selected.Number)
+					m.stage = stageReverting
+					return m, m.startRevert()
+				}
+			case "q", "esc", "ctrl+c", "enter":
+				return m, tea.Quit
+			}
 		}
-		return m, nil
 
 	case openInBrowserMsg:
 		m.stage = stagePickStrategy
-		return m, m.showStrategies()
+		m.showStrategies()
+		return m, nil
 
 	case mergedMsg:
 		if msg.err != nil {
 			m.err = msg.err
-			m.status = fmt.Sprintf("Failed to merge PR #%d: %v", m.selected.Number, msg.err)
+			m.status = fmt.Sprintf("Failed to merge PR #%d: %s", m.selected.Number, gh.Friendly(msg.err))
 		} else {
 			m.status = fmt.Sprintf("Successfully merged PR #%d using %s strategy",
 				m.selected.Number, strings.ToUpper(m.strat[2:]))
 			if m.deleteBr {
-				m.status += " and deleted branch"
+				m.status += fmt.Sprintf(" and deleted branch\n(undo with: shippr restore-branch %s %d)",
+					m.selRepo, m.selected.Number)
 			}
+			m.merged = true
 		}
 		m.stage = stageDone
-		return m, nil
+		return m, m.quitIfAsked()
 	}
 
 	if m.stage == stagePickPR || m.stage == stagePickStrategy {
@@ -379,18 +875,193 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m model) showStrategies() tea.Cmd {
+func (m *model) showStrategies() {
 	items := []list.Item{
 		strategyItem{flag: mergeSquash, label: "Squash (default)"},
 		strategyItem{flag: mergeRebase, label: "Rebase"},
 		strategyItem{flag: mergeMerge, label: "Merge"},
 	}
+	m.list.ResetFilter()
+	m.list.SetFilteringEnabled(false)
 	m.list.Title = "Choose merge strategy"
 	m.list.SetItems(items)
 	m.list.Select(0)
+}
+
+// setPRItems (re)populates the picker with the fetched PRs and re-applies
+// the active view.
+func (m *model) setPRItems() {
+	rows, prefixes, queued := m.prs, map[int]string(nil), map[int]int(nil)
+	if !m.source.multi {
+		rows, prefixes = orderByStack(m.prs)
+		if len(rows) > 0 {
+			queued = queuePositions(rows[0].Repo)
+		}
+	}
+	items := make([]list.Item, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, prItem{
+			PR:         r.PR,
+			repo:       r.Repo,
+			reasons:    r.Reasons,
+			showRepo:   m.source.multi,
+			staleAfter: m.source.staleAfter,
+			tree:       prefixes[r.PR.Number],
+			queuePos:   queued[r.PR.Number],
+		})
+	}
+	if m.more {
+		items = append(items, loadMoreItem{shown: len(rows)})
+	}
+	m.filter.setItems(items)
+	m.list.SetFilteringEnabled(true)
+	m.list.ResetFilter()
+	m.list.SetItems(items)
+	m.applyView()
+}
+
+// cycleState moves to the next PR state (open → merged → closed → all) and
+// back to the initial limit.
+func (m *model) cycleState() {
+	for i, st := range gh.States {
+		if st == m.state {
+			m.state = gh.States[(i+1)%len(gh.States)]
+			break
+		}
+	}
+	m.limit = m.source.limit
+	m.keepIndex = 0
+}
+
+// loadMore fetches the next prPageSize PRs, keeping the cursor in place.
+func (m *model) loadMore() tea.Cmd {
+	m.limit = max(m.limit, gh.DefaultLimit) + prPageSize
+	m.keepIndex = m.list.Index()
+	return m.refetch()
+}
+
+// refetch reloads the PR list after the state or limit changed.
+func (m *model) refetch() tea.Cmd {
+	m.notice = ""
+	m.stage = stageFetch
+	m.scan = scanProgress{seq: m.scan.seq + 1}
+	m.stopFetch()
+	m.fetchCtx, m.stopFetch = context.WithCancel(m.ctx)
+	return m.fetchPRs()
+}
+
+// quitIfAsked ends the program if the user quit while a merge was running.
+func (m model) quitIfAsked() tea.Cmd {
+	if m.quitting {
+		return tea.Quit
+	}
 	return nil
 }
 
+// restartDetails cancels fetching the previous PR's details before the
+// next PR's are fetched.
+func (m *model) restartDetails() {
+	m.stopDetails()
+	m.detailsCtx, m.stopDetails = context.WithCancel(m.ctx)
+}
+
+// revalidated applies a fresh listing that arrived while cached rows were
+// already on screen, keeping the filter and cursor where the user left them.
+func (m model) revalidated(msg fetchedMsg) model {
+	cached := m.cachedAt
+	m.cachedAt = time.Time{}
+	if msg.err != nil {
+		// Keep what's on screen rather than bailing out.
+		m.notice = fmt.Sprintf("Refresh failed, showing cached PRs: %s", gh.Friendly(msg.err))
+		m.list.Title = m.listTitle()
+		return m
+	}
+	if len(msg.prs) == 0 && m.state == gh.StateOpen && m.stage == stagePickPR {
+		m.status = m.source.empty
+		m.stage = stageDone
+		return m
+	}
+	m.me = msg.me
+	m.filter.setMe(msg.me)
+	if msg.quota != nil {
+		m.quota = msg.quota
+	}
+	if cached.IsZero() || (fingerprint(msg.prs) == fingerprint(m.prs) && msg.more == m.more) {
+		m.prs, m.more = msg.prs, msg.more
+		if msg.warning != "" {
+			m.notice = msg.warning
+		}
+		m.list.Title = m.listTitle()
+		return m
+	}
+	m.prs, m.more = msg.prs, msg.more
+	if m.stage != stagePickPR {
+		// Rebuilding the list now would clobber e.g. the strategy picker;
+		// the fresh rows show up on the next listing.
+		return m
+	}
+	m.notice = msg.warning
+	if len(m.prs) == 0 {
+		m.notice = fmt.Sprintf("No %s pull requests", m.state)
+	}
+	m.refreshItems()
+	return m
+}
+
+// refreshItems rebuilds the picker after m.prs changed underneath the
+// user, keeping their filter and cursor.
+func (m *model) refreshItems() {
+	index, filter := m.list.Index(), m.list.FilterValue()
+	m.setPRItems()
+	if filter != "" && filter != m.list.FilterValue() {
+		m.list.SetFilterText(filter)
+	}
+	m.list.Select(index)
+}
+
+func (m *model) cycleView(delta int) {
+	if len(m.views) == 0 {
+		return
+	}
+	m.view = (m.view + delta + len(m.views)) % len(m.views)
+	m.applyView()
+}
+
+// listTitle names the picker, including the selected state and page for
+// sources that support them.
+func (m model) listTitle() string {
+	if !m.source.states {
+		return m.source.title + m.refreshing(m.cachedAt) + m.scanStatus()
+	}
+	title := fmt.Sprintf("%s%s %s", strings.ToUpper(m.state[:1]), m.state[1:], m.source.title)
+	if m.more {
+		title += fmt.Sprintf(" · first %d shown, more available", len(m.prs))
+	}
+	return title + m.refreshing(m.cachedAt) + m.scanStatus()
+}
+
+// refreshing describes cached data being revalidated, or "" once fresh.
+func (m model) refreshing(cachedAt time.Time) string {
+	if cachedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(" · ⟳ refreshing (cached %s ago)", relativeAge(cachedAt.Format(time.RFC3339), time.Now()))
+}
+
+func (m *model) applyView() {
+	m.list.Title = m.listTitle()
+	if len(m.views) == 0 {
+		return
+	}
+	v := m.views[m.view]
+	if v.Query == "" {
+		m.list.ResetFilter()
+		return
+	}
+	m.list.Title = fmt.Sprintf("%s · %s", m.listTitle(), v.Name)
+	m.list.SetFilterText(v.Query)
+}
+
 func (m model) renderPRSummary() string {
 	if m.prDetails == nil {
 		return m.spinner.View() + " " + infoStyle.Render("Loading PR details...")
@@ -405,6 +1076,9 @@ func (m model) renderPRSummary() string {
 			infoStyle.Render(fmt.Sprintf("by %s • %s → %s", pr.Author.Login, pr.HeadRefName, pr.BaseRefName)),
 	))
 	content.WriteString("\n\n")
+	if r := m.refreshing(m.detailsCachedAt); r != "" {
+		content.WriteString(infoStyle.Render(strings.TrimPrefix(r, " · ")) + "\n")
+	}
 
 	// Status and stats
 	statusInfo := fmt.Sprintf("%s  %s  %s",
@@ -412,7 +1086,25 @@ func (m model) renderPRSummary() string {
 		successStyle.Render(fmt.Sprintf("+%d", pr.Additions)),
 		errorStyle.Render(fmt.Sprintf("-%d", pr.Deletions)),
 	)
-	content.WriteString(statusInfo + "\n\n")
+	content.WriteString(statusInfo + "\n")
+	var readiness []string
+	for _, part := range []string{
+		checksIcon(gh.CheckState(pr.StatusCheckRollup)),
+		reviewDecisionLabel(pr.ReviewDecision),
+		mergeStateLabel(pr.MergeStateStatus),
+	} {
+		if part != "" {
+			readiness = append(readiness, part)
+		}
+	}
+	if len(readiness) > 0 {
+		content.WriteString(strings.Join(readiness, "  ") + "\n")
+	}
+	content.WriteString(renderMergeQueue(m.mergeQueue, pr.BaseRefName))
+	if m.notice != "" {
+		content.WriteString(accentStyle.Render(m.notice) + "\n")
+	}
+	content.WriteString("\n")
 
 	// Description
 	if pr.Body != "" {
@@ -471,11 +1163,26 @@ func (m model) renderPRSummary() string {
 	}
 
 	// Actions
+	merge := highlightStyle.Render("m") + " Merge\n"
+	if pr.State == "MERGED" {
+		merge = highlightStyle.Render("X") + " Revert\n"
+	}
+	if m.usesMergeQueue() {
+		merge = highlightStyle.Render("m") + " Add to Merge Queue\n"
+		if m.mergeQueue.InQueue {
+			merge = highlightStyle.Render("D") + " Remove from Merge Queue\n"
+		}
+	}
 	content.WriteString(borderStyle.Render(
 		titleStyle.Render("Actions:") + "\n" +
 			highlightStyle.Render("a") + " Approve  " +
 			highlightStyle.Render("r") + " Request Changes  " +
-			highlightStyle.Render("m") + " Merge\n" +
+			merge +
+			highlightStyle.Render("R") + " Re-request Review  " +
+			highlightStyle.Render("u") + " Update Branch  " +
+			highlightStyle.Render("U") + " Update (rebase)  " +
+			highlightStyle.Render("L") + " Local Rebase\n" +
+			stateAction(pr.State) +
 			highlightStyle.Render("b") + " Back  " +
 			highlightStyle.Render("q") + " Quit",
 	))
@@ -483,17 +1190,37 @@ func (m model) renderPRSummary() string {
 	return content.String()
 }
 
+func stateAction(state string) string {
+	switch state {
+	case "OPEN":
+		return highlightStyle.Render("c") + " Close  "
+	case "CLOSED":
+		return highlightStyle.Render("o") + " Reopen  "
+	}
+	return ""
+}
+
 func (m model) View() string {
 	var content string
 
 	switch m.stage {
 	case stageFetch:
+		status := "Fetching pull requests..."
+		if m.scan.total > 0 {
+			status = fmt.Sprintf("Scanning repositories... %d/%d", m.scan.scanned, m.scan.total)
+		}
 		content = fmt.Sprintf("%s\n%s %s\n",
 			getLogo(),
 			m.spinner.View(),
-			infoStyle.Render("Fetching pull requests..."))
+			infoStyle.Render(status))
 	case stagePickPR:
 		content = m.list.View()
+		if m.notice != "" {
+			content += "\n" + accentStyle.Render(m.notice)
+		}
+		if m.quota != nil {
+			content += "\n" + quotaStyle(m.quota).Render(m.quota.String())
+		}
 	case stageViewSummary:
 		content = m.renderPRSummary()
 	case stageConfirmOpen:
@@ -512,8 +1239,19 @@ func (m model) View() string {
 				prNumberStyle.Render(fmt.Sprintf("#%d", m.selected.Number)),
 				infoStyle.Render(strings.ToUpper(m.strat[2:]))),
 			fmt.Sprintf("\nDelete branch '%s' after merging? (y/N)\n", branchStyle.Render(m.selected.HeadRefName)))
-	case stageMerging:
+	case stageMerging, stageReverting:
 		content = fmt.Sprintf("%s %s\n", m.spinner.View(), infoStyle.Render(m.status))
+		if m.quitting {
+			content += "\n" + accentStyle.Render(m.notice)
+		}
+	case stageConfirmRevertMerge:
+		content = m.renderConfirmRevertMerge()
+	case stageOfferUpdate:
+		content = m.renderOfferUpdate()
+	case stageRebase:
+		content = m.renderRebase()
+	case stageConfirmStack, stageMergingStack:
+		content = m.renderStack()
 	case stageDone:
 		if m.err != nil {
 			content = fmt.Sprintf("%s\n%s\n\n%s",
@@ -521,21 +1259,23 @@ func (m model) View() string {
 				errorStyle.Render(m.status),
 				infoStyle.Render("(press q/esc/ctrl+c to quit)"))
 		} else {
+			hint := "(press q/esc/ctrl+c to quit)"
+			if m.merged {
+				hint = "(press X to revert this merge, q/esc/ctrl+c to quit)"
+			}
 			content = fmt.Sprintf("%s\n%s\n\n%s",
 				successStyle.Render("✅ Success"),
 				successStyle.Render(m.status),
-				infoStyle.Render("(press q/esc/ctrl+c to quit)"))
+				infoStyle.Render(hint))
 		}
 	default:
 		content = ""
 	}
 
-	return content
+	return dryRunBanner() + content
 }
 
-func formatLabels(labels []struct {
-	Name string `json:"name"`
-}) string {
+func formatLabels(labels []gh.Label) string {
 	if len(labels) == 0 {
 		return "-"
 	}
@@ -546,6 +1286,18 @@ func formatLabels(labels []struct {
 	return strings.Join(labelNames, ", ")
 }
 
+// finishedAt is when a PR was merged or closed, or its last update while
+// still open, as an RFC 3339 string that sorts chronologically.
+func finishedAt(pr gh.PR) string {
+	switch {
+	case pr.MergedAt != "":
+		return pr.MergedAt
+	case pr.ClosedAt != "":
+		return pr.ClosedAt
+	}
+	return pr.UpdatedAt
+}
+
 func formatStatus(pr gh.PR) string {
 	if pr.State != "OPEN" {
 		return pr.State
@@ -556,15 +1308,29 @@ func formatStatus(pr gh.PR) string {
 	return "OPEN"
 }
 
-func runList(org string) error {
-	rows, err := gh.ListOpenPRsForOrg(context.Background(), org, 0)
+func runList(ctx context.Context, org string, repoLimit int, opts gh.ListOptions) error {
+	bar := newScanBar()
+	res, err := gh.StreamOrgPRs(ctx, org, repoLimit, opts, bar.update)
+	bar.clear()
+	if err := ctx.Err(); err != nil {
+		// Interrupted: skip the partial listing.
+		return err
+	}
 	if err != nil {
 		return err
 	}
+	rows := res.PRs
 	if len(rows) == 0 {
-		fmt.Printf("%s\n", infoStyle.Render(fmt.Sprintf("No open PRs for %s", titleStyle.Render(org))))
+		fmt.Printf("%s\n", infoStyle.Render(fmt.Sprintf("No %s PRs for %s", opts.State, titleStyle.Render(org))))
+		printTruncation(ctx, res)
 		return nil
 	}
+	if opts.State != gh.StateOpen {
+		// Most recently merged/closed first.
+		sort.SliceStable(rows, func(i, j int) bool {
+			return finishedAt(rows[i].PR) > finishedAt(rows[j].PR)
+		})
+	}
 
 	// Determine layout with additional columns
 	maxRepo := 0
@@ -651,9 +1417,70 @@ func runList(org string) error {
 			statusColored,
 		)
 	}
+	printTruncation(ctx, res)
 	return nil
 }
 
+// scanTarget resolves the --org and --repo flags, or an <org/repo>
+// argument, of commands built on listOpenPRs. It returns the repository to
+// list, or "" to scan all of org; ok is false for invalid usage.
+func scanTarget(fs *flag.FlagSet, org, repo string) (string, bool) {
+	switch {
+	case org != "" && repo != "":
+		return gh.Slug(org, repo), fs.NArg() == 0
+	case org == "" && fs.NArg() == 1 && strings.Contains(fs.Arg(0), "/"):
+		return fs.Arg(0), repo == ""
+	}
+	return "", org != "" && fs.NArg() == 0
+}
+
+// listOpenPRs lists every open PR of repo, or of org's repositories when
+// repo is empty, for commands that act on all of them (stale, bots, apply).
+// They change PRs based on what they list, so the cache is skipped.
+func listOpenPRs(ctx context.Context, org, repo string, repoLimit int) ([]gh.RepoPR, error) {
+	opts := gh.ListOptions{State: gh.StateOpen, Limit: gh.LimitAll, Fresh: true}
+	if repo != "" {
+		prs, err := gh.ListPRs(ctx, repo, opts)
+		return repoRows(repo, prs), err
+	}
+	bar := newScanBar()
+	res, err := gh.StreamOrgPRs(ctx, org, repoLimit, opts, bar.update)
+	bar.clear()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(res.Failed); n > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("… %d repositories could not be listed (%s)", n, strings.Join(res.Failed, ", "))))
+	}
+	return res.PRs, nil
+}
+
+// printTruncation warns when limits or errors may have hidden PRs or
+// repositories, and shows the API quota left after the scan.
+func printTruncation(ctx context.Context, res *gh.OrgPRs) {
+	if n := len(res.Failed); n > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("… %d repositories could not be listed (%s)", n, strings.Join(res.Failed, ", "))))
+	}
+	if res.ReposTruncated {
+		fmt.Println(accentStyle.Render("… more repositories not listed; raise --repo-limit or use --all"))
+	}
+	if n := len(res.Truncated); n > 0 {
+		fmt.Println(accentStyle.Render(fmt.Sprintf("… %d repositories have more PRs than --limit (%s); raise --limit or use --all",
+			n, strings.Join(res.Truncated, ", "))))
+	}
+	if rl, err := gh.GetRateLimit(ctx); err == nil {
+		fmt.Println(quotaStyle(rl).Render(rl.String()))
+	}
+}
+
+// quotaStyle highlights the API quota once less than a tenth is left.
+func quotaStyle(rl *gh.RateLimits) lipgloss.Style {
+	if q := rl.GraphQL; q.Remaining*10 < q.Limit {
+		return errorStyle
+	}
+	return infoStyle
+}
+
 // termWidth returns terminal width using $COLUMNS if available.
 func termWidth() int {
 	if v := os.Getenv("COLUMNS"); v != "" {
@@ -702,70 +1529,264 @@ func stringsRepeat(s string, count int) string {
 	return string(b)
 }
 
+// globalOptions are the flags shared by the TUI and every subcommand.
+type globalOptions struct {
+	configPath string
+	theme      string
+	noColor    bool
+	dryRun     bool
+	noCache    bool
+}
+
+func (o *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", "", "Path to config file (default: $SHIPPR_CONFIG or <user config dir>/shippr/config.json)")
+	fs.StringVar(&o.theme, "theme", "", "Color theme: auto, dark, light, high-contrast, monochrome or a theme from config")
+	fs.BoolVar(&o.noColor, "no-color", false, "Disable colors (also honored via NO_COLOR)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Show the gh commands mutating actions would run without running them")
+	fs.BoolVar(&o.noCache, "no-cache", false, "Always fetch from GitHub instead of showing cached results first")
+}
+
+// setup loads the config file, applies the selected theme, opens the audit
+// log and response cache and turns on dry-run mode if requested. Flags take precedence over config values.
+func (o *globalOptions) setup() (*config.Config, error) {
+	if o.dryRun {
+		dryrun.Enable(nil)
+	}
+	path := o.configPath
+	if path == "" {
+		p, err := config.Path()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.Theme
+	if o.theme != "" {
+		name = o.theme
+	}
+	if err := setupTheme(name, cfg.Themes, noColorRequested(o.noColor)); err != nil {
+		return nil, err
+	}
+	logPath, err := cfg.AuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	audit.Enable(logPath)
+	ttl, err := cfg.CacheMaxAge()
+	if err != nil {
+		return nil, err
+	}
+	if !o.noCache && ttl > 0 {
+		dir, err := config.CacheDir()
+		if err != nil {
+			return nil, err
+		}
+		gh.EnableCache(dir, ttl)
+	}
+	return cfg, nil
+}
+
+// Exit codes let scripts tell failures apart; 2 and 4 match gh's codes for
+// cancellation and missing authentication.
+const (
+	exitError        = 1
+	exitCancelled    = 2
+	exitNotFound     = 3
+	exitUnauthorized = 4
+	exitRateLimited  = 5
+	exitNotMergeable = 6
+	exitNetwork      = 7
+)
+
+// exitCode maps err to the process exit status.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, tea.ErrInterrupted):
+		return exitCancelled
+	case errors.Is(err, gh.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, gh.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, gh.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, gh.ErrNotMergeable):
+		return exitNotMergeable
+	case errors.Is(err, gh.ErrNetwork):
+		return exitNetwork
+	}
+	return exitError
+}
+
+// fatal explains err and exits with its exit code.
+func fatal(err error) {
+	code := exitCode(err)
+	if code == exitCancelled {
+		fmt.Fprintln(os.Stderr, "cancelled")
+	} else {
+		fmt.Fprintf(os.Stderr, "error: %s\n", gh.Friendly(err))
+	}
+	os.Exit(code)
+}
+
+// signalContext is the root context of a command: Ctrl+C or SIGTERM
+// cancel it, stopping gh reads in flight. Changes already sent to GitHub
+// are left to finish (see gh.mutate).
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// runTUI runs the interactive picker, optionally without the alt screen. It
+// returns the error that ended the session, if any, so it sets the exit code.
+func runTUI(m model, noAlt bool) error {
+	if len(os.Getenv("DEBUG")) > 0 {
+		if f, err := tea.LogToFile("debug.log", "debug"); err == nil {
+			defer f.Close()
+		}
+	}
+	// Quitting cancels whatever the session still has in flight.
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+	m.bind(ctx)
+	var p *tea.Program
+	if noAlt {
+		p = tea.NewProgram(m)
+	} else {
+		p = tea.NewProgram(m, tea.WithAltScreen())
+	}
+	final, err := p.Run()
+	cancel()
+	reportDryRun()
+	if err != nil {
+		return err
+	}
+	if fm, ok := final.(model); ok && fm.err != nil {
+		return fm.err
+	}
+	return nil
+}
+
+func listCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	var org, state string
+	var limit, repoLimit int
+	var all bool
+	fs.StringVar(&org, "org", "", "GitHub organization")
+	fs.StringVar(&state, "state", gh.StateOpen, "PR state: open, merged, closed or all")
+	fs.IntVar(&limit, "limit", gh.DefaultLimit, "Maximum PRs per repository")
+	fs.IntVar(&repoLimit, "repo-limit", 100, "Maximum repositories to scan")
+	fs.BoolVar(&all, "all", false, "Fetch every PR of every repository, ignoring --limit and --repo-limit")
+	opts.register(fs)
+	fs.Usage = func() {
+		// Show logo + usage for list subcommand
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr list --org <org> [--state open|merged|closed|all] [--limit N] [--repo-limit N] [--all]")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if org == "" || !slices.Contains(gh.States, state) {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+	if all {
+		limit, repoLimit = gh.LimitAll, gh.LimitAll
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	if err := runList(ctx, org, repoLimit, gh.ListOptions{State: state, Limit: limit}); err != nil {
+		fatal(err)
+	}
+}
+
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "list" {
-		fs := flag.NewFlagSet("list", flag.ExitOnError)
-		var org string
-		fs.StringVar(&org, "org", "", "GitHub organization")
-		fs.Usage = func() {
-			// Show logo + usage for list subcommand
-			fmt.Print(getLogo() + "\n")
-			fmt.Fprintln(os.Stderr, "Usage: shippr list --org <org>")
-			fmt.Fprintln(os.Stderr)
-			fs.PrintDefaults()
-		}
-		_ = fs.Parse(os.Args[2:])
-		if org == "" {
-			fs.Usage()
-			os.Exit(1)
-		}
-		if err := runList(org); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "list":
+			listCmd(os.Args[2:])
+			return
+		case "inbox":
+			inboxCmd(os.Args[2:])
+			return
+		case "mine":
+			mineCmd(os.Args[2:])
+			return
+		case "queue":
+			queueCmd(os.Args[2:])
+			return
+		case "history":
+			historyCmd(os.Args[2:])
+			return
+		case "restore-branch":
+			restoreBranchCmd(os.Args[2:])
+			return
+		case "revert":
+			revertCmd(os.Args[2:])
+			return
+		case "stale":
+			staleCmd(os.Args[2:])
+			return
+		case "bots":
+			botsCmd(os.Args[2:])
+			return
+		case "apply":
+			applyCmd(os.Args[2:])
+			return
+		case "watch":
+			watchCmd(os.Args[2:])
+			return
 		}
-		return
 	}
+	var opts globalOptions
 	var org, repo string
-	var noAlt bool
+	var noAlt, all bool
+	var limit, repoLimit int
 	// Global usage with logo
 	flag.Usage = func() {
 		fmt.Print(getLogo() + "\n")
-		fmt.Fprintln(os.Stderr, "Usage: shippr list --org <org> | shippr --org <org> --repo <repo> | shippr <org/repo>")
+		fmt.Fprintln(os.Stderr, "Usage: shippr list --org <org> | shippr inbox | shippr mine | shippr queue | shippr history | shippr restore-branch | shippr revert | shippr stale | shippr bots | shippr apply | shippr watch | shippr --org <org> [--repo <repo>] | shippr <org/repo>")
 		fmt.Fprintln(os.Stderr)
 		flag.PrintDefaults()
 	}
 	flag.StringVar(&org, "org", "", "GitHub organization or user")
 	flag.StringVar(&repo, "repo", "", "Repository name")
 	flag.BoolVar(&noAlt, "no-alt", false, "Disable alternate screen (render in normal screen)")
+	flag.IntVar(&limit, "limit", gh.DefaultLimit, "How many PRs to fetch at first (load more from the list)")
+	flag.IntVar(&repoLimit, "repo-limit", 100, "Maximum repositories to scan with --org and no --repo")
+	flag.BoolVar(&all, "all", false, "Fetch every PR instead of the first --limit")
+	opts.register(flag.CommandLine)
 	flag.Parse()
+	if all {
+		limit, repoLimit = gh.LimitAll, gh.LimitAll
+	}
 
-	repoSlug := ""
-	if org != "" && repo != "" {
-		repoSlug = gh.Slug(org, repo)
-	} else if flag.NArg() == 1 {
-		repoSlug = flag.Arg(0)
-	} else {
+	var source prSource
+	switch {
+	case org != "" && repo != "":
+		source = repoSource(gh.Slug(org, repo), limit)
+	case org != "" && flag.NArg() == 0:
+		source = orgSource(org, repoLimit, limit)
+	case flag.NArg() == 1:
+		source = repoSource(flag.Arg(0), limit)
+	default:
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	if len(os.Getenv("DEBUG")) > 0 {
-		if f, err := tea.LogToFile("debug.log", "debug"); err == nil {
-			defer f.Close()
-		}
-	}
-
-	if noAlt {
-		p := tea.NewProgram(initialModel(context.Background(), repoSlug))
-		if _, err := p.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	cfg, err := opts.setup()
+	if err != nil {
+		fatal(err)
 	}
-	p := tea.NewProgram(initialModel(context.Background(), repoSlug), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+	ctx, stop := signalContext()
+	defer stop()
+	if err := runTUI(initialModel(ctx, source, cfg), noAlt); err != nil {
+		fatal(err)
 	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usesMergeQueue reports whether the selected PR's base branch only accepts
+// merges through GitHub's merge queue.
+func (m model) usesMergeQueue() bool {
+	return m.mergeQueue != nil && m.mergeQueue.Required
+}
+
+func (m model) enqueueSelected() tea.Cmd {
+	id, number := m.mergeQueue.PullRequestID, m.selected.Number
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
+		defer cancel()
+		err := gh.EnqueuePR(ctx, m.selRepo, number, id)
+		return prActionMsg{done: fmt.Sprintf("#%d added to the merge queue", number), err: err}
+	}
+}
+
+func (m model) dequeueSelected() tea.Cmd {
+	id, number := m.mergeQueue.PullRequestID, m.selected.Number
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
+		defer cancel()
+		err := gh.DequeuePR(ctx, m.selRepo, number, id)
+		return prActionMsg{done: fmt.Sprintf("#%d removed from the merge queue", number), err: err}
+	}
+}
+
+// renderMergeQueue explains why the strategy picker is skipped and, once
+// queued, where the PR stands.
+func renderMergeQueue(mq *gh.MergeQueueStatus, base string) string {
+	if mq == nil || !mq.Required {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(accentStyle.Render("⧗ "+base+" requires the merge queue") + " " +
+		infoStyle.Render("(merge method is set by the queue, so there is no strategy to pick)") + "\n")
+	if !mq.InQueue {
+		return b.String()
+	}
+	parts := []string{fmt.Sprintf("position %d", mq.Position)}
+	if mq.State != "" {
+		parts = append(parts, mergeQueueStateLabel(mq.State))
+	}
+	if mq.ETA > 0 {
+		parts = append(parts, fmt.Sprintf("~%dm to merge", max(int(mq.ETA.Round(time.Minute).Minutes()), 1)))
+	}
+	b.WriteString(successStyle.Render("In merge queue: ") + strings.Join(parts, " · ") + "\n")
+	return b.String()
+}
+
+func mergeQueueStateLabel(state string) string {
+	switch state {
+	case "AWAITING_CHECKS":
+		return "waiting for checks"
+	case "MERGEABLE":
+		return "ready to merge"
+	case "UNMERGEABLE":
+		return errorStyle.Render("unmergeable")
+	case "LOCKED":
+		return "locked"
+	case "QUEUED":
+		return "queued"
+	}
+	return strings.ToLower(state)
+}
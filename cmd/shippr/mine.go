@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+)
+
+// mineSource lists the current user's open PRs across all repositories,
+// flagging those without activity for staleAfter.
+func mineSource(limit int, staleAfter time.Duration) prSource {
+	return prSource{
+		title:      "My Pull Requests",
+		empty:      "You have no open pull requests",
+		multi:      true,
+		timeout:    60 * time.Second,
+		staleAfter: staleAfter,
+		fetch: func(ctx context.Context, _ gh.ListOptions) ([]gh.RepoPR, error) {
+			return gh.Mine(ctx, limit)
+		},
+	}
+}
+
+func mineCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("mine", flag.ExitOnError)
+	var limit int
+	var stale string
+	var noAlt bool
+	fs.IntVar(&limit, "limit", 100, "Maximum number of PRs")
+	fs.StringVar(&stale, "stale", "7d", "Flag PRs without activity for this long (e.g. 3d, 2w)")
+	fs.BoolVar(&noAlt, "no-alt", false, "Disable alternate screen (render in normal screen)")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr mine [--limit N] [--stale 7d]")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	staleAfter, err := query.ParseAge(stale)
+	if err != nil {
+		fatal(err)
+	}
+	cfg, err := opts.setup()
+	if err != nil {
+		fatal(err)
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	if err := runTUI(initialModel(ctx, mineSource(limit, staleAfter), cfg), noAlt); err != nil {
+		fatal(err)
+	}
+}
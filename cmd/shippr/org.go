@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"git-shippr/internal/gh"
+)
+
+// orgSource lists PRs across up to repoLimit repositories of org, adding
+// each repository's PRs to the picker as soon as it has been scanned.
+func orgSource(org string, repoLimit, limit int) prSource {
+	return prSource{
+		title:   "Pull Requests · " + org,
+		empty:   fmt.Sprintf("No open pull requests found in %s", titleStyle.Render(org)),
+		multi:   true,
+		timeout: 5 * time.Minute,
+		states:  true,
+		limit:   limit,
+		stream: func(ctx context.Context, opts gh.ListOptions, onBatch func(gh.OrgBatch)) (*gh.OrgPRs, error) {
+			return gh.StreamOrgPRs(ctx, org, repoLimit, opts, onBatch)
+		},
+	}
+}
+
+// scanProgress tracks a streamed org scan in the picker.
+type scanProgress struct {
+	// seq identifies the scan; batches from an abandoned scan (e.g. before
+	// the state changed) are dropped.
+	seq            int
+	scanned, total int
+	done           bool
+}
+
+// orgBatchMsg carries one batch of a streamed scan. next waits for the
+// following message from the same scan.
+type orgBatchMsg struct {
+	seq   int
+	batch gh.OrgBatch
+	me    string
+	next  tea.Cmd
+}
+
+// orgDoneMsg ends a streamed scan.
+type orgDoneMsg struct {
+	seq   int
+	res   *gh.OrgPRs
+	quota *gh.RateLimits
+	err   error
+}
+
+// streamPRs starts a streamed scan and returns a command delivering its
+// first message; each orgBatchMsg brings the command for the next one.
+func (m model) streamPRs() tea.Cmd {
+	seq, opts := m.scan.seq, m.listOptions()
+	ch := make(chan tea.Msg, 16)
+	var next tea.Cmd
+	next = func() tea.Msg { return <-ch }
+	go func() {
+		ctx, cancel := context.WithTimeout(m.fetchCtx, m.source.timeout)
+		defer cancel()
+		if err := gh.EnsureGH(ctx); err != nil {
+			ch <- orgDoneMsg{seq: seq, err: err}
+			return
+		}
+		// "me" in queries is best effort; a failure here shouldn't block listing.
+		me, _ := gh.CurrentUser(ctx)
+		res, err := m.source.stream(ctx, opts, func(b gh.OrgBatch) {
+			ch <- orgBatchMsg{seq: seq, batch: b, me: me, next: next}
+		})
+		quota, _ := gh.GetRateLimit(ctx)
+		ch <- orgDoneMsg{seq: seq, res: res, quota: quota, err: err}
+	}()
+	return next
+}
+
+func (m model) handleOrgBatch(msg orgBatchMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != m.scan.seq {
+		// Drain the abandoned scan so its goroutine can finish.
+		return m, msg.next
+	}
+	b := msg.batch
+	m.scan.scanned, m.scan.total = b.Scanned, b.Total
+	if b.Repo == "" {
+		m.prs, m.more = nil, false
+		m.me = msg.me
+		m.filter.setMe(msg.me)
+	}
+	m.prs = append(m.prs, b.PRs...)
+	m.more = m.more || b.Truncated
+	switch {
+	case m.stage == stageFetch && len(m.prs) > 0:
+		m.setPRItems()
+		m.stage = stagePickPR
+	case m.stage == stagePickPR && len(b.PRs) > 0, m.stage == stageViewSummary && len(b.PRs) > 0:
+		// The list isn't on screen in the summary, so it can be rebuilt
+		// underneath it; other stages reuse the list widget.
+		m.refreshItems()
+	default:
+		m.list.Title = m.listTitle()
+	}
+	return m, msg.next
+}
+
+func (m model) handleOrgDone(msg orgDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != m.scan.seq {
+		return m, nil
+	}
+	m.scan.done = true
+	if msg.quota != nil {
+		m.quota = msg.quota
+	}
+	if msg.err != nil {
+		if m.stage == stagePickPR && len(m.prs) > 0 {
+			m.notice = fmt.Sprintf("Scan stopped early: %s", gh.Friendly(msg.err))
+			m.list.Title = m.listTitle()
+			return m, nil
+		}
+		m.err = msg.err
+		m.status = fmt.Sprintf("Failed to fetch PRs: %s", gh.Friendly(msg.err))
+		m.stage = stageDone
+		return m, nil
+	}
+	if len(m.prs) == 0 && m.state == gh.StateOpen {
+		m.status = m.source.empty
+		m.stage = stageDone
+		return m, nil
+	}
+	if n := len(msg.res.Failed); n > 0 {
+		m.notice = fmt.Sprintf("%d repositories could not be listed", n)
+	} else if len(m.prs) == 0 {
+		m.notice = fmt.Sprintf("No %s pull requests", m.state)
+	}
+	if m.stage == stageFetch {
+		m.setPRItems()
+		m.stage = stagePickPR
+	} else if m.stage == stagePickPR || m.stage == stageViewSummary {
+		m.refreshItems()
+	}
+	if m.keepIndex > 0 {
+		m.list.Select(m.keepIndex)
+		m.keepIndex = 0
+	}
+	return m, nil
+}
+
+// scanStatus describes a streamed scan still in progress, or "".
+func (m model) scanStatus() string {
+	if m.source.stream == nil || m.scan.done {
+		return ""
+	}
+	return fmt.Sprintf(" · scanning %d/%d repos", m.scan.scanned, m.scan.total)
+}
+
+// scanBar draws "<bar> 37/212 repos scanned" on stderr while runList scans
+// an org. It stays silent when stderr isn't a terminal.
+type scanBar struct {
+	bar progress.Model
+	tty bool
+}
+
+func newScanBar() *scanBar {
+	opts := []progress.Option{progress.WithWidth(30), progress.WithoutPercentage()}
+	if palette.Primary != "" {
+		opts = append(opts, progress.WithSolidFill(palette.Primary))
+	}
+	fi, err := os.Stderr.Stat()
+	return &scanBar{bar: progress.New(opts...), tty: err == nil && fi.Mode()&os.ModeCharDevice != 0}
+}
+
+func (s *scanBar) update(b gh.OrgBatch) {
+	if !s.tty || b.Total == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r%s %d/%d repos scanned", s.bar.ViewAs(float64(b.Scanned)/float64(b.Total)), b.Scanned, b.Total)
+}
+
+// clear erases the bar so the listing starts on a clean line.
+func (s *scanBar) clear() {
+	if s.tty {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// prRowDescription renders the second line of a PR list row: merge
+// readiness markers first, then author, age, branch and label chips.
+func prRowDescription(p gh.PR, now time.Time) string {
+	var parts []string
+	switch p.State {
+	case "MERGED":
+		parts = append(parts, successStyle.Render(strings.TrimSpace("⇄ merged "+ago(p.MergedAt, now))))
+	case "CLOSED":
+		parts = append(parts, errorStyle.Render(strings.TrimSpace("⊘ closed "+ago(p.ClosedAt, now))))
+	}
+	if icon := checksIcon(gh.CheckState(p.StatusCheckRollup)); icon != "" && p.State == "OPEN" {
+		parts = append(parts, icon)
+	}
+	if review := reviewDecisionLabel(p.ReviewDecision); review != "" && p.State == "OPEN" {
+		parts = append(parts, review)
+	}
+	if p.Mergeable == "CONFLICTING" && p.State == "OPEN" {
+		parts = append(parts, errorStyle.Render("⚠ conflict"))
+	}
+	if p.MergeStateStatus == "BEHIND" {
+		parts = append(parts, accentStyle.Render("↓ behind"))
+	}
+	if p.IsDraft {
+		parts = append(parts, infoStyle.Faint(true).Render("◌ draft"))
+	}
+	meta := []string{"@" + p.Author.Login}
+	if age := relativeAge(p.CreatedAt, now); age != "" {
+		meta = append(meta, age)
+	}
+	meta = append(meta, branchStyle.Render(p.HeadRefName))
+	parts = append(parts, strings.Join(meta, " · "))
+	if chips := labelChips(p.Labels); chips != "" {
+		parts = append(parts, chips)
+	}
+	return strings.Join(parts, "  ")
+}
+
+func checksIcon(state string) string {
+	switch state {
+	case gh.ChecksPassing:
+		return successStyle.Render("✓ checks")
+	case gh.ChecksFailing:
+		return errorStyle.Render("✗ checks")
+	case gh.ChecksPending:
+		return accentStyle.Render("● checks")
+	default:
+		return ""
+	}
+}
+
+func reviewDecisionLabel(decision string) string {
+	switch decision {
+	case "APPROVED":
+		return successStyle.Render("✓ approved")
+	case "CHANGES_REQUESTED":
+		return errorStyle.Render("✗ changes requested")
+	case "REVIEW_REQUIRED":
+		return accentStyle.Render("◎ review required")
+	default:
+		return ""
+	}
+}
+
+func mergeStateLabel(state string) string {
+	switch state {
+	case "CLEAN":
+		return successStyle.Render("✓ ready to merge")
+	case "BEHIND":
+		return accentStyle.Render("↓ behind base")
+	case "DIRTY":
+		return errorStyle.Render("⚠ conflict")
+	case "BLOCKED":
+		return errorStyle.Render("⛔ blocked")
+	case "UNSTABLE":
+		return accentStyle.Render("● unstable checks")
+	default:
+		return ""
+	}
+}
+
+// labelChips renders labels with their GitHub colors. In monochrome mode
+// they fall back to bracketed names.
+func labelChips(labels []gh.Label) string {
+	chips := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if palette.IsMonochrome() || l.Color == "" {
+			chips = append(chips, "["+l.Name+"]")
+			continue
+		}
+		bg := "#" + strings.TrimPrefix(l.Color, "#")
+		chip := lipgloss.NewStyle().
+			Background(lipgloss.Color(bg)).
+			Foreground(lipgloss.Color(chipForeground(bg))).
+			Padding(0, 1).
+			Render(l.Name)
+		chips = append(chips, chip)
+	}
+	return strings.Join(chips, " ")
+}
+
+// chipForeground picks black or white text for legibility on bg.
+func chipForeground(bg string) string {
+	hex := strings.TrimPrefix(bg, "#")
+	if len(hex) != 6 {
+		return "#000000"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return "#000000"
+	}
+	r, g, b := float64(v>>16&0xff), float64(v>>8&0xff), float64(v&0xff)
+	if 0.299*r+0.587*g+0.114*b > 150 {
+		return "#000000"
+	}
+	return "#FFFFFF"
+}
+
+// ago phrases relativeAge for after a verb: "3h ago", "just now", or
+// nothing when ts is missing.
+func ago(ts string, now time.Time) string {
+	switch age := relativeAge(ts, now); age {
+	case "":
+		return ""
+	case "now":
+		return "just now"
+	default:
+		return age + " ago"
+	}
+}
+
+// relativeAge formats the time since an RFC 3339 timestamp compactly
+// ("5m", "3h", "2d", "6w", "4mo", "1y").
+func relativeAge(ts string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"git-shippr/internal/config"
+	"git-shippr/internal/dryrun"
+	"git-shippr/internal/queue"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func queueUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr queue add <org/repo> <pr>...")
+		fmt.Fprintln(os.Stderr, "       shippr queue remove <org/repo> <pr>...")
+		fmt.Fprintln(os.Stderr, "       shippr queue list <org/repo>")
+		fmt.Fprintln(os.Stderr, "       shippr queue clear <org/repo>")
+		fmt.Fprintln(os.Stderr, "       shippr queue run [flags] <org/repo>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+}
+
+func queueCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("queue", flag.ExitOnError)
+	var strategy string
+	var deleteBranch, noTUI, noAlt bool
+	var poll, timeout time.Duration
+	fs.StringVar(&strategy, "strategy", "squash", "Merge strategy for run: squash, rebase or merge")
+	fs.BoolVar(&deleteBranch, "delete-branch", false, "Delete head branches after merging (run)")
+	fs.DurationVar(&poll, "poll", 30*time.Second, "How often to poll checks while waiting (run)")
+	fs.DurationVar(&timeout, "timeout", 45*time.Minute, "Give up on a PR whose checks take longer than this (run)")
+	fs.BoolVar(&noTUI, "no-tui", false, "Print progress lines instead of the interactive view (run)")
+	fs.BoolVar(&noAlt, "no-alt", false, "Disable alternate screen (render in normal screen)")
+	opts.register(fs)
+	fs.Usage = queueUsage(fs)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	action := args[0]
+	_ = fs.Parse(args[1:])
+	rest := fs.Args()
+	if len(rest) < 1 || !strings.Contains(rest[0], "/") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	repo := rest[0]
+	strategy, err := parseStrategy(strategy)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+	dir, err := config.StateDir()
+	if err != nil {
+		fatal(err)
+	}
+
+	switch action {
+	case "add", "remove":
+		numbers, err := parsePRNumbers(rest[1:])
+		if err != nil || len(numbers) == 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		var lines []string
+		err = editQueue(dir, repo, func(q *queue.Queue) error {
+			lines = lines[:0]
+			for _, n := range numbers {
+				if action == "add" {
+					if err := q.Add(n, "", time.Now()); err != nil {
+						return err
+					}
+					lines = append(lines, successStyle.Render(fmt.Sprintf("Enqueued #%d at position %d", n, q.Position(n))))
+				} else if q.Remove(n) {
+					lines = append(lines, successStyle.Render(fmt.Sprintf("Removed #%d from the queue", n)))
+				} else {
+					lines = append(lines, infoStyle.Render(fmt.Sprintf("#%d is not queued", n)))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(strings.Join(lines, "\n"))
+		reportQueueDryRun()
+	case "list":
+		q, err := queue.Load(dir, repo)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(renderQueue(q, nil, ""))
+	case "clear":
+		if err := editQueue(dir, repo, func(q *queue.Queue) error { q.Entries = nil; return nil }); err != nil {
+			fatal(err)
+		}
+		fmt.Println(successStyle.Render("Queue cleared"))
+		reportQueueDryRun()
+	case "run":
+		p := &queue.Processor{
+			Dir:          dir,
+			Repo:         repo,
+			Backend:      queue.GHBackend{},
+			Strategy:     strategy,
+			DeleteBranch: deleteBranch,
+			PollInterval: poll,
+			CheckTimeout: timeout,
+		}
+		if err := runQueue(p, noTUI, noAlt); err != nil {
+			fatal(err)
+		}
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+}
+
+func parsePRNumbers(args []string) ([]int, error) {
+	numbers := make([]int, 0, len(args))
+	for _, a := range args {
+		n, err := strconv.Atoi(strings.TrimPrefix(a, "#"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PR number %q", a)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// editQueue applies fn to repo's queue under its lock. Under --dry-run fn
+// runs on a copy that is thrown away.
+func editQueue(dir, repo string, fn func(*queue.Queue) error) error {
+	if dryrun.Enabled() {
+		q, err := queue.Load(dir, repo)
+		if err != nil {
+			return err
+		}
+		return fn(q)
+	}
+	return queue.Modify(dir, repo, fn)
+}
+
+func reportQueueDryRun() {
+	if dryrun.Enabled() {
+		fmt.Println(accentStyle.Render("DRY RUN: the queue was not changed"))
+	}
+}
+
+func runQueue(p *queue.Processor, noTUI, noAlt bool) error {
+	q, err := queue.Load(p.Dir, p.Repo)
+	if err != nil {
+		return err
+	}
+	unlock, err := q.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if dryrun.Enabled() {
+		// Work on a scratch copy so a dry run leaves the real queue untouched.
+		if p.Dir, err = scratchQueueDir(p.Dir, p.Repo); err != nil {
+			return err
+		}
+		defer os.RemoveAll(p.Dir)
+	}
+
+	if noTUI {
+		if dryrun.Enabled() {
+			dryrun.Enable(os.Stdout)
+		}
+		p.OnEvent = func(e queue.Event) {
+			line := fmt.Sprintf("%s #%d %s", time.Now().Format(time.TimeOnly), e.Number, e.Status)
+			if e.Reason != "" {
+				line += ": " + e.Reason
+			}
+			fmt.Println(line)
+		}
+		ctx, stop := signalContext()
+		defer stop()
+		return p.Run(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(primary)
+	m := queueModel{ctx: ctx, cancel: cancel, proc: p, queue: q, spinner: s}
+	var prog *tea.Program
+	if noAlt {
+		prog = tea.NewProgram(m)
+	} else {
+		prog = tea.NewProgram(m, tea.WithAltScreen())
+	}
+	p.OnEvent = func(e queue.Event) { prog.Send(queueEventMsg(e)) }
+	final, err := prog.Run()
+	reportDryRun()
+	if err != nil {
+		return err
+	}
+	if qm, ok := final.(queueModel); ok && qm.err != nil {
+		return qm.err
+	}
+	return nil
+}
+
+// scratchQueueDir copies repo's queue into a temporary state dir.
+func scratchQueueDir(dir, repo string) (string, error) {
+	tmp, err := os.MkdirTemp("", "shippr-queue-")
+	if err != nil {
+		return "", err
+	}
+	q, err := queue.Load(dir, repo)
+	if err != nil {
+		return "", err
+	}
+	scratch, err := queue.Load(tmp, repo)
+	if err != nil {
+		return "", err
+	}
+	scratch.Entries, scratch.History = q.Entries, q.History
+	return tmp, scratch.Save()
+}
+
+type queueEventMsg queue.Event
+
+type queueDoneMsg struct{ err error }
+
+type queueModel struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	proc    *queue.Processor
+	queue   *queue.Queue
+	spinner spinner.Model
+	log     []string
+	done    bool
+	err     error
+	// quitting is set once the user asked to stop; the program exits when
+	// the processor returns, so a merge already sent finishes first.
+	quitting bool
+}
+
+func (m queueModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return queueDoneMsg{err: m.proc.Run(m.ctx)}
+	})
+}
+
+func (m queueModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case queueEventMsg:
+		line := fmt.Sprintf("%s #%d %s", time.Now().Format(time.TimeOnly), msg.Number, msg.Status)
+		if msg.Reason != "" {
+			line += ": " + msg.Reason
+		}
+		m.log = append(m.log, line)
+		if q, err := queue.Load(m.proc.Dir, m.proc.Repo); err == nil {
+			m.queue = q
+		}
+		return m, nil
+	case queueDoneMsg:
+		m.done = true
+		if msg.err != nil && m.ctx.Err() == nil {
+			m.err = msg.err
+		}
+		if q, err := queue.Load(m.proc.Dir, m.proc.Repo); err == nil {
+			m.queue = q
+		}
+		if m.quitting {
+			return m, tea.Quit
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.cancel()
+			if m.done {
+				return m, tea.Quit
+			}
+			// Cancelling stops waits and reads at once, but a merge in
+			// flight is waited for, as in the PR picker.
+			m.quitting = true
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m queueModel) View() string {
+	var b strings.Builder
+	b.WriteString(dryRunBanner())
+	b.WriteString(renderQueue(m.queue, &m.spinner, m.proc.Strategy))
+	if len(m.log) > 0 {
+		b.WriteString("\n" + titleStyle.Render("Progress:") + "\n")
+		for _, l := range m.log[max(0, len(m.log)-10):] {
+			b.WriteString(infoStyle.Render("  "+l) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("Queue stopped: "+m.err.Error()) + "\n")
+	case m.quitting:
+		b.WriteString(accentStyle.Render("Stopping once GitHub confirms the current step...") + "\n")
+	case m.done:
+		b.WriteString(successStyle.Render("Queue is empty") + "\n")
+	}
+	b.WriteString(infoStyle.Render("(press q to stop; the queue is saved and resumes on the next run)"))
+	return b.String()
+}
+
+// renderQueue lists queued PRs with their position and state, followed by
+// recently finished entries. spin animates the active entry when non-nil.
+func renderQueue(q *queue.Queue, spin *spinner.Model, strategy string) string {
+	var b strings.Builder
+	title := fmt.Sprintf("Merge queue · %s", q.Repo)
+	if strategy != "" {
+		title += fmt.Sprintf(" · %s", strings.ToUpper(strings.TrimPrefix(strategy, "--")))
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+	if len(q.Entries) == 0 {
+		b.WriteString(infoStyle.Render("  (empty)") + "\n")
+	}
+	for i, e := range q.Entries {
+		marker := infoStyle.Render("○")
+		if e.Status != queue.Queued && spin != nil {
+			marker = spin.View()
+		}
+		line := fmt.Sprintf("  %s %s %s %s", marker,
+			accentStyle.Render(fmt.Sprintf("%2d.", i+1)),
+			prNumberStyle.Render(fmt.Sprintf("#%d", e.Number)),
+			infoStyle.Render(string(e.Status)))
+		if e.Reason != "" {
+			line += " " + infoStyle.Render("("+e.Reason+")")
+		}
+		if e.Title != "" {
+			line += " " + e.Title
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(q.History) > 0 {
+		b.WriteString("\n" + titleStyle.Render("Recently finished:") + "\n")
+		for _, e := range q.History[:min(len(q.History), 5)] {
+			icon := successStyle.Render("✓")
+			if e.Status == queue.Failed {
+				icon = errorStyle.Render("✗")
+			}
+			line := fmt.Sprintf("  %s %s %s", icon, prNumberStyle.Render(fmt.Sprintf("#%d", e.Number)), string(e.Status))
+			if e.Reason != "" {
+				line += ": " + e.Reason
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// queuePositions maps PR numbers to their place in repo's local merge queue.
+// Errors just hide the markers; the picker works without a queue.
+func queuePositions(repo string) map[int]int {
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil
+	}
+	q, err := queue.Load(dir, repo)
+	if err != nil {
+		return nil
+	}
+	pos := make(map[int]int, len(q.Entries))
+	for i, e := range q.Entries {
+		pos[e.Number] = i + 1
+	}
+	return pos
+}
+
+// toggleQueue adds the highlighted PR to the local merge queue, or removes
+// it if it is already there.
+func (m *model) toggleQueue(it prItem) {
+	dir, err := config.StateDir()
+	if err != nil {
+		m.notice = fmt.Sprintf("Queue unavailable: %v", err)
+		return
+	}
+	err = editQueue(dir, it.repo, func(q *queue.Queue) error {
+		if q.Remove(it.Number) {
+			m.notice = fmt.Sprintf("Removed #%d from the merge queue", it.Number)
+			return nil
+		}
+		if err := q.Add(it.Number, it.PR.Title, time.Now()); err != nil {
+			return err
+		}
+		m.notice = fmt.Sprintf("Queued #%d at position %d; run `shippr queue run %s` to process", it.Number, q.Position(it.Number), it.repo)
+		return nil
+	})
+	if err != nil {
+		m.notice = fmt.Sprintf("Could not update queue: %v", err)
+		return
+	}
+	if dryrun.Enabled() {
+		m.notice += " (dry run: not saved)"
+	}
+	idx := m.list.Index()
+	m.setPRItems()
+	m.list.Select(idx)
+}
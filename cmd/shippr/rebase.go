@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/gitx"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	rebaseStart = iota
+	rebaseContinue
+	rebaseSkip
+	rebasePush
+	rebaseAbort
+)
+
+type rebaseMsg struct {
+	step      int
+	checkout  *gitx.Checkout
+	conflicts []string
+	err       error
+}
+
+// needsUpdate reports whether merging should first offer to bring the
+// branch up to date (behind its base) or to rebase it locally (conflicts).
+func needsUpdate(pr *gh.PRDetails) bool {
+	if pr == nil {
+		return false
+	}
+	return pr.MergeStateStatus == "BEHIND" || pr.MergeStateStatus == "DIRTY" || pr.Mergeable == "CONFLICTING"
+}
+
+func (m model) startRebase() tea.Cmd {
+	return func() tea.Msg {
+		if m.prDetails == nil {
+			return rebaseMsg{step: rebaseStart, err: fmt.Errorf("no PR selected")}
+		}
+		ctx, cancel := context.WithTimeout(m.ctx, 5*time.Minute)
+		defer cancel()
+		pr := m.prDetails
+		co, err := gitx.CheckoutPR(ctx, m.selRepo, pr.Number, pr.BaseRefName, pr.HeadRepo(), pr.HeadRefName)
+		if err != nil {
+			return rebaseMsg{step: rebaseStart, err: err}
+		}
+		conflicts, err := co.Rebase(ctx)
+		return rebaseMsg{step: rebaseStart, checkout: co, conflicts: conflicts, err: err}
+	}
+}
+
+func (m model) rebaseStep(step int) tea.Cmd {
+	co := m.checkout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 2*time.Minute)
+		defer cancel()
+		msg := rebaseMsg{step: step, checkout: co}
+		switch step {
+		case rebaseContinue:
+			msg.conflicts, msg.err = co.Continue(ctx)
+		case rebaseSkip:
+			msg.conflicts, msg.err = co.Skip(ctx)
+		case rebasePush:
+			msg.err = co.Push(ctx)
+		case rebaseAbort:
+			msg.err = co.Abort(ctx)
+		}
+		return msg
+	}
+}
+
+func (m model) handleRebase(msg rebaseMsg) (tea.Model, tea.Cmd) {
+	switch msg.step {
+	case rebaseStart:
+		if msg.err != nil && msg.checkout == nil {
+			m.err = msg.err
+			m.status = fmt.Sprintf("Failed to prepare local rebase: %v", msg.err)
+			m.stage = stageDone
+			return m, nil
+		}
+		m.checkout = msg.checkout
+		m.stage = stageRebase
+		return m.rebaseStopped(msg), nil
+	case rebaseContinue, rebaseSkip:
+		return m.rebaseStopped(msg), nil
+	case rebasePush:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Push failed: %v", msg.err)
+			return m, nil
+		}
+		_ = m.checkout.Remove()
+		m.checkout = nil
+		m.notice = "Pushed rebased branch with --force-with-lease; checks will re-run"
+		m.stage = stageViewSummary
+		return m, m.fetchPRDetails()
+	case rebaseAbort:
+		_ = m.checkout.Remove()
+		m.checkout = nil
+		m.conflicts = nil
+		m.emptyCommit = false
+		m.notice = "Local rebase aborted"
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Local rebase aborted: %v", msg.err)
+		}
+		m.stage = stageViewSummary
+		return m, nil
+	}
+	return m, nil
+}
+
+// rebaseStopped records where the rebase stands after a step.
+func (m model) rebaseStopped(msg rebaseMsg) model {
+	m.conflicts = msg.conflicts
+	m.emptyCommit = errors.Is(msg.err, gitx.ErrEmptyCommit)
+	m.notice = ""
+	if msg.err != nil && !m.emptyCommit {
+		m.notice = msg.err.Error()
+	}
+	return m
+}
+
+func (m model) updateRebase(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "c":
+		if len(m.conflicts) > 0 {
+			m.notice = "Continuing rebase..."
+			return m, m.rebaseStep(rebaseContinue)
+		}
+	case "s":
+		if len(m.conflicts) > 0 || m.emptyCommit {
+			m.notice = "Skipping commit..."
+			return m, m.rebaseStep(rebaseSkip)
+		}
+	case "p":
+		if len(m.conflicts) == 0 && !m.emptyCommit {
+			m.notice = "Pushing with --force-with-lease..."
+			return m, m.rebaseStep(rebasePush)
+		}
+	case "x", "esc":
+		m.notice = "Aborting rebase..."
+		return m, m.rebaseStep(rebaseAbort)
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) renderOfferUpdate() string {
+	pr := m.prDetails
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Branch needs attention before merging") + "\n\n")
+	if pr.MergeStateStatus == "BEHIND" {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("PR #%d is behind %s; branch protection may reject the merge.", pr.Number, pr.BaseRefName)) + "\n\n")
+		b.WriteString(highlightStyle.Render("u") + " Update branch (merge base in)  " +
+			highlightStyle.Render("U") + " Update branch (rebase)\n")
+	} else {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("PR #%d has conflicts with %s.", pr.Number, pr.BaseRefName)) + "\n\n")
+		b.WriteString(highlightStyle.Render("L") + " Rebase locally and resolve conflicts\n")
+	}
+	b.WriteString(highlightStyle.Render("n") + " Merge anyway  " +
+		highlightStyle.Render("b") + " Back  " +
+		highlightStyle.Render("q") + " Quit")
+	return b.String()
+}
+
+func (m model) renderRebase() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Local rebase of #%d onto %s", m.prDetails.Number, m.prDetails.BaseRefName)) + "\n")
+	b.WriteString(infoStyle.Render("Checkout: ") + branchStyle.Render(m.checkout.Dir) + "\n\n")
+	if len(m.conflicts) > 0 {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Conflicted files (%d):", len(m.conflicts))) + "\n")
+		for _, f := range m.conflicts {
+			b.WriteString("  " + errorStyle.Render("U") + " " + f + "\n")
+		}
+		b.WriteString("\n" + infoStyle.Render("Resolve the files in the checkout above, then continue.") + "\n\n")
+		b.WriteString(highlightStyle.Render("c") + " Continue rebase  " +
+			highlightStyle.Render("s") + " Skip commit  ")
+	} else if m.emptyCommit {
+		b.WriteString(errorStyle.Render("Nothing left to commit: "+m.prDetails.BaseRefName+" already has this commit's changes.") + "\n\n")
+		b.WriteString(highlightStyle.Render("s") + " Skip commit (git rebase --skip)  ")
+	} else {
+		b.WriteString(successStyle.Render("Rebase complete.") + " " +
+			infoStyle.Render("Push to update the PR branch.") + "\n\n")
+		b.WriteString(highlightStyle.Render("p") + " Push (--force-with-lease)  ")
+	}
+	b.WriteString(highlightStyle.Render("x") + " Abort  " + highlightStyle.Render("q") + " Quit (keep checkout)")
+	if m.notice != "" {
+		b.WriteString("\n\n" + accentStyle.Render(m.notice))
+	}
+	return b.String()
+}
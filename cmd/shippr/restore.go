@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/audit"
+	"git-shippr/internal/config"
+	"git-shippr/internal/gh"
+)
+
+func restoreBranchCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("restore-branch", flag.ExitOnError)
+	var branch, sha string
+	fs.StringVar(&branch, "branch", "", "Branch name to recreate (default: the PR's head branch)")
+	fs.StringVar(&sha, "sha", "", "Commit to point the branch at (default: the recorded head SHA)")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr restore-branch [--branch name] [--sha sha] <org/repo> <pr>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	rest := fs.Args()
+	if len(rest) != 2 || !strings.Contains(rest[0], "/") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	repo := rest[0]
+	numbers, err := parsePRNumbers(rest[1:])
+	if err != nil {
+		fatal(err)
+	}
+	number := numbers[0]
+	cfg, err := opts.setup()
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	head, err := gh.GetPRHead(ctx, repo, number)
+	if err != nil {
+		fatal(err)
+	}
+	if head.CrossRepository && branch == "" {
+		fatal(fmt.Errorf("#%d comes from a fork; its branch was not deleted from %s", number, repo))
+	}
+	if branch == "" {
+		branch = head.Branch
+	}
+	source := "--sha"
+	if sha == "" {
+		sha, source = recordedHeadSHA(cfg, repo, number, branch)
+	}
+	if sha == "" {
+		sha, source = head.SHA, "GitHub's record of the PR head"
+	}
+	if existing, err := gh.RefSHA(ctx, repo, branch); err == nil {
+		fatal(fmt.Errorf("branch %s already exists in %s at %s", branch, repo, shortSHA(existing)))
+	}
+	if err := gh.CreateBranch(ctx, repo, branch, sha); err != nil {
+		fatal(err)
+	}
+	reportDryRun()
+	fmt.Println(successStyle.Render(fmt.Sprintf("Restored %s in %s at %s", branch, repo, shortSHA(sha))) +
+		" " + infoStyle.Render("(from "+source+")"))
+}
+
+// recordedHeadSHA looks up the SHA the branch had when shippr deleted it.
+func recordedHeadSHA(cfg *config.Config, repo string, number int, branch string) (sha, source string) {
+	path, err := cfg.AuditLogPath()
+	if err != nil || path == "" {
+		return "", ""
+	}
+	entries, err := audit.Read(path, audit.Filter{Repo: repo})
+	if err != nil {
+		return "", ""
+	}
+	e, ok := audit.DeletedBranch(entries, repo, number, branch)
+	if !ok {
+		return "", ""
+	}
+	return e.HeadSHA, fmt.Sprintf("audit log, %s %s", e.Action, e.Time.Local().Format("2006-01-02 15:04"))
+}
+
+func shortSHA(sha string) string {
+	return sha[:min(len(sha), 7)]
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/gitx"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// revertResult is the revert PR opened for a merged PR.
+type revertResult struct {
+	Number int
+	URL    string
+	Title  string
+	Branch string
+	Base   string
+}
+
+// revertPR opens a PR that reverts the merge (or squash/rebase) commit of a
+// merged PR, the way GitHub's "Revert" button does.
+func revertPR(ctx context.Context, repo string, number int) (*revertResult, error) {
+	pr, err := gh.GetPRDetails(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	if pr.State != "MERGED" || pr.MergeCommit == nil || pr.MergeCommit.Oid == "" {
+		return nil, fmt.Errorf("#%d is not merged (state %s); only merged PRs can be reverted", number, strings.ToLower(pr.State))
+	}
+	co, err := gitx.CloneBase(ctx, repo, pr.BaseRefName)
+	if err != nil {
+		return nil, err
+	}
+	defer co.Remove()
+
+	branch := fmt.Sprintf("revert-%d-%s", number, pr.HeadRefName)
+	if _, err := co.Revert(ctx, pr.MergeCommit.Oid, branch); err != nil {
+		return nil, err
+	}
+	if err := co.PushBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+	res := &revertResult{
+		Title:  fmt.Sprintf("Revert %q", pr.Title),
+		Branch: branch,
+		Base:   pr.BaseRefName,
+	}
+	body := fmt.Sprintf("Reverts %s#%d\n\nThis reverts commit %s (%s).", repo, number, pr.MergeCommit.Oid, pr.URL)
+	res.Number, res.URL, err = gh.CreatePR(ctx, repo, pr.BaseRefName, branch, res.Title, body)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func revertCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("revert", flag.ExitOnError)
+	var strategy string
+	var merge, noMerge, deleteBranch bool
+	fs.StringVar(&strategy, "strategy", "squash", "Strategy for merging the revert PR: squash, rebase or merge")
+	fs.BoolVar(&merge, "merge", false, "Merge the revert PR without asking")
+	fs.BoolVar(&noMerge, "no-merge", false, "Only open the revert PR")
+	fs.BoolVar(&deleteBranch, "delete-branch", true, "Delete the revert branch after merging it")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr revert [--merge|--no-merge] [--strategy squash] <org/repo> <pr>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	rest := fs.Args()
+	if len(rest) != 2 || !strings.Contains(rest[0], "/") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	repo := rest[0]
+	numbers, err := parsePRNumbers(rest[1:])
+	if err != nil {
+		fatal(err)
+	}
+	// Checked now: a typo found after the revert PR is open is too late.
+	strategy, err = parseStrategy(strategy)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Reverting #%d in %s...", numbers[0], repo)))
+	revertCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	res, err := revertPR(revertCtx, repo, numbers[0])
+	cancel()
+	if err != nil {
+		fatal(err)
+	}
+	if res.Number == 0 {
+		// Dry run: nothing was created, so there is nothing to merge.
+		reportDryRun()
+		return
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("Opened revert PR #%d: %s", res.Number, res.URL)))
+
+	if noMerge || (!merge && !confirm(fmt.Sprintf("Merge #%d now with %s? (y/N) ", res.Number, strings.ToUpper(strings.TrimPrefix(strategy, "--"))))) {
+		return
+	}
+	// The clock starts after the prompt: the user may take their time.
+	ctx, cancel = context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	if err := gh.MergePR(ctx, repo, res.Number, strategy, deleteBranch); err != nil {
+		fatal(err)
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("Merged revert PR #%d", res.Number)))
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no.
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+type revertMsg struct {
+	res *revertResult
+	err error
+}
+
+func (m model) startRevert() tea.Cmd {
+	repo, number := m.selRepo, m.selected.Number
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 5*time.Minute)
+		defer cancel()
+		res, err := revertPR(ctx, repo, number)
+		return revertMsg{res: res, err: err}
+	}
+}
+
+func (m model) handleRevert(msg revertMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = fmt.Sprintf("Revert failed: %s", gh.Friendly(msg.err))
+		m.stage = stageDone
+		return m, m.quitIfAsked()
+	}
+	res := msg.res
+	if res.Number == 0 {
+		// Dry run: nothing was pushed or opened.
+		m.status = fmt.Sprintf("Dry run: would open a revert PR for #%d", m.selected.Number)
+		m.stage = stageDone
+		return m, m.quitIfAsked()
+	}
+	if m.quitting {
+		m.status = fmt.Sprintf("Opened revert PR #%d: %s", res.Number, res.URL)
+		m.stage = stageDone
+		return m, tea.Quit
+	}
+	m.revert = res
+	m.merged = false
+	m.selected = &gh.PR{Number: res.Number, Title: res.Title, HeadRefName: res.Branch, BaseRefName: res.Base}
+	m.prDetails = nil
+	m.stage = stageConfirmRevertMerge
+	return m, nil
+}
+
+func (m model) updateConfirmRevertMerge(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.stage = stagePickStrategy
+		m.showStrategies()
+	case "n", "N", "enter":
+		m.status = fmt.Sprintf("Opened revert PR #%d: %s", m.revert.Number, m.revert.URL)
+		m.stage = stageDone
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) renderConfirmRevertMerge() string {
+	return fmt.Sprintf("%s\n%s\n%s\n\n%s",
+		titleStyle.Render("Revert PR opened"),
+		fmt.Sprintf("PR %s: %s", prNumberStyle.Render(fmt.Sprintf("#%d", m.revert.Number)), m.revert.Title),
+		infoStyle.Render(m.revert.URL),
+		"Merge it now? (y/N)")
+}
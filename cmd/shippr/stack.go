@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/stack"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stackMerge tracks a bottom-up merge of a PR stack.
+type stackMerge struct {
+	repo     string
+	trunk    string
+	chain    []gh.PR
+	strat    string
+	deleteBr bool
+	next     int
+	log      []string
+}
+
+type stackStepMsg struct {
+	index int
+	log   []string
+	err   error
+}
+
+// orderByStack puts stacked PRs directly below their parent and returns the
+// tree guide to draw before each PR number. Without stacks rows are kept as is.
+func orderByStack(rows []gh.RepoPR) ([]gh.RepoPR, map[int]string) {
+	prs := make([]gh.PR, len(rows))
+	byNumber := make(map[int]gh.RepoPR, len(rows))
+	for i, r := range rows {
+		prs[i] = r.PR
+		byNumber[r.PR.Number] = r
+	}
+	roots := stack.Build(prs)
+	if !stack.HasStacks(roots) {
+		return rows, nil
+	}
+	ordered := make([]gh.RepoPR, 0, len(rows))
+	prefixes := make(map[int]string, len(rows))
+	for _, n := range stack.Flatten(roots) {
+		ordered = append(ordered, byNumber[n.PR.Number])
+		prefixes[n.PR.Number] = stack.Prefix(n)
+	}
+	return ordered, prefixes
+}
+
+func (m model) confirmStack(it prItem) (tea.Model, tea.Cmd) {
+	prs := make([]gh.PR, 0, len(m.prs))
+	for _, r := range m.prs {
+		if r.Repo == it.repo {
+			prs = append(prs, r.PR)
+		}
+	}
+	roots := stack.Build(prs)
+	chain := stack.Chain(roots, it.Number)
+	if len(chain) < 2 {
+		m.notice = fmt.Sprintf("#%d is not stacked on another PR", it.Number)
+		return m, nil
+	}
+	// A merge commit keeps the commits the children were built on, so they
+	// still apply cleanly once retargeted; squash and rebase rewrite them.
+	sm := &stackMerge{repo: it.repo, trunk: stack.Trunk(chain), strat: mergeMerge, deleteBr: true}
+	for _, n := range chain {
+		sm.chain = append(sm.chain, n.PR)
+	}
+	m.stack = sm
+	m.notice = ""
+	m.stage = stageConfirmStack
+	return m, nil
+}
+
+func (m model) updateConfirmStack(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "s":
+		m.stack.strat = mergeSquash
+	case "r":
+		m.stack.strat = mergeRebase
+	case "m":
+		m.stack.strat = mergeMerge
+	case "d":
+		m.stack.deleteBr = !m.stack.deleteBr
+	case "y", "enter":
+		m.stage = stageMergingStack
+		return m, m.mergeStackStep()
+	case "b", "esc":
+		m.stack = nil
+		m.stage = stagePickPR
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// mergeStackStep merges the next PR of the stack. Its branch is only
+// deleted after every PR based on it has been retargeted to the trunk, so
+// GitHub never auto-closes the children.
+func (m model) mergeStackStep() tea.Cmd {
+	sm := m.stack
+	i := sm.next
+	pr := sm.chain[i]
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 3*time.Minute)
+		defer cancel()
+		var log []string
+		if i > 0 {
+			if err := waitMergeable(ctx, sm.repo, pr.Number, sm.trunk); err != nil {
+				return stackStepMsg{index: i, err: err}
+			}
+		}
+		// The PR list may be truncated; every child must be retargeted
+		// before the branch goes, or GitHub closes it.
+		dependents, err := stackDependents(ctx, sm.repo, pr.HeadRefName)
+		if err != nil {
+			return stackStepMsg{index: i, err: err}
+		}
+		if err := gh.MergePR(ctx, sm.repo, pr.Number, sm.strat, false); err != nil {
+			return stackStepMsg{index: i, log: log, err: err}
+		}
+		log = append(log, fmt.Sprintf("merged #%d into %s", pr.Number, baseFor(sm, i)))
+		for _, n := range dependents {
+			if err := gh.EditBase(ctx, sm.repo, n, sm.trunk); err != nil {
+				return stackStepMsg{index: i, log: log, err: err}
+			}
+			log = append(log, fmt.Sprintf("retargeted #%d to %s", n, sm.trunk))
+		}
+		if sm.deleteBr {
+			if err := gh.DeleteBranch(ctx, sm.repo, pr.HeadRefName); err != nil {
+				return stackStepMsg{index: i, log: log, err: err}
+			}
+			log = append(log, fmt.Sprintf("deleted branch %s", pr.HeadRefName))
+		}
+		return stackStepMsg{index: i, log: log}
+	}
+}
+
+// baseFor is the branch chain[i] merges into: the trunk, since every PR is
+// retargeted before its turn.
+func baseFor(sm *stackMerge, i int) string {
+	if i == 0 {
+		return sm.chain[0].BaseRefName
+	}
+	return sm.trunk
+}
+
+// stackDependents returns the open PRs of repo based on branch.
+func stackDependents(ctx context.Context, repo, branch string) ([]int, error) {
+	prs, err := gh.ListPRs(ctx, repo, gh.ListOptions{State: gh.StateOpen, Limit: gh.LimitAll})
+	if err != nil {
+		return nil, err
+	}
+	var numbers []int
+	for _, pr := range prs {
+		if pr.BaseRefName == branch {
+			numbers = append(numbers, pr.Number)
+		}
+	}
+	return numbers, nil
+}
+
+// waitMergeable polls until GitHub has recomputed mergeability after a
+// retarget to base, and fails if the PR now conflicts with it.
+func waitMergeable(ctx context.Context, repo string, number int, base string) error {
+	for {
+		d, err := gh.GetPRDetails(ctx, repo, number)
+		if err != nil {
+			return err
+		}
+		switch d.Mergeable {
+		case "CONFLICTING":
+			return fmt.Errorf("#%d conflicts with %s: rebase #%d onto %s, push, then merge the rest of the stack",
+				number, base, number, base)
+		case "UNKNOWN", "":
+		default:
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for GitHub to compute mergeability of #%d", number)
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+func (m model) handleStackStep(msg stackStepMsg) (tea.Model, tea.Cmd) {
+	sm := m.stack
+	sm.log = append(sm.log, msg.log...)
+	if msg.err != nil {
+		completed := "(nothing)"
+		if len(sm.log) > 0 {
+			completed = strings.Join(sm.log, "\n  ")
+		}
+		m.err = msg.err
+		m.status = fmt.Sprintf("Stack merge stopped at #%d: %s\n\nCompleted:\n  %s",
+			sm.chain[msg.index].Number, gh.Friendly(msg.err), completed)
+		m.stage = stageDone
+		return m, m.quitIfAsked()
+	}
+	sm.next = msg.index + 1
+	if sm.next < len(sm.chain) && m.quitting {
+		// Stop between PRs, where the stack is in a consistent state.
+		m.err = context.Canceled
+		m.status = fmt.Sprintf("Stack merge cancelled before #%d\n\nCompleted:\n  %s",
+			sm.chain[sm.next].Number, strings.Join(sm.log, "\n  "))
+		m.stage = stageDone
+		return m, tea.Quit
+	}
+	if sm.next < len(sm.chain) {
+		return m, m.mergeStackStep()
+	}
+	m.status = fmt.Sprintf("Merged stack of %d PRs into %s:\n  %s", len(sm.chain), sm.trunk, strings.Join(sm.log, "\n  "))
+	m.stage = stageDone
+	return m, m.quitIfAsked()
+}
+
+func (m model) renderStack() string {
+	sm := m.stack
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Merge stack into %s (bottom-up)", sm.trunk)) + "\n\n")
+	for i, pr := range sm.chain {
+		marker := infoStyle.Render("○")
+		switch {
+		case i < sm.next:
+			marker = successStyle.Render("✓")
+		case i == sm.next && m.stage == stageMergingStack:
+			marker = m.spinner.View()
+		}
+		fmt.Fprintf(&b, "  %s %s %s %s\n", marker,
+			prNumberStyle.Render(fmt.Sprintf("#%d", pr.Number)), pr.Title,
+			branchStyle.Render(pr.HeadRefName+" → "+pr.BaseRefName))
+	}
+	b.WriteString("\n")
+	if m.stage == stageMergingStack {
+		for _, l := range sm.log {
+			b.WriteString(infoStyle.Render("  "+l) + "\n")
+		}
+		if m.quitting {
+			b.WriteString("\n" + accentStyle.Render(m.notice) + "\n")
+		}
+		return b.String()
+	}
+	del := "no"
+	if sm.deleteBr {
+		del = "yes"
+	}
+	fmt.Fprintf(&b, "Strategy: %s   Delete branches: %s\n",
+		accentStyle.Render(strings.ToUpper(sm.strat[2:])), accentStyle.Render(del))
+	b.WriteString(infoStyle.Render("Each PR is merged, its children are retargeted to "+sm.trunk+", then its branch is deleted.") + "\n")
+	if sm.strat != mergeMerge {
+		b.WriteString(accentStyle.Render("Squash and rebase rewrite each PR's commits, so the next PR may conflict and need a rebase.") + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(highlightStyle.Render("y") + " Merge stack  " +
+		highlightStyle.Render("s/r/m") + " Strategy  " +
+		highlightStyle.Render("d") + " Toggle delete  " +
+		highlightStyle.Render("b") + " Back")
+	return b.String()
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/dryrun"
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+	"git-shippr/internal/stale"
+)
+
+func staleCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("stale", flag.ExitOnError)
+	var org, repo, olderThan, closeAfter, message string
+	var label, comment, apply bool
+	var repoLimit int
+	policy := stale.Policy{Label: stale.DefaultLabel}
+	fs.StringVar(&org, "org", "", "GitHub organization to scan")
+	fs.StringVar(&repo, "repo", "", "Only this repository of --org")
+	fs.IntVar(&repoLimit, "repo-limit", 100, "Maximum repositories to scan")
+	fs.StringVar(&olderThan, "older-than", "30d", "PRs without activity for this long are stale (e.g. 30d, 6w)")
+	fs.BoolVar(&comment, "comment", false, "Comment a warning on PRs that just went stale")
+	fs.BoolVar(&label, "label", false, "Label PRs that just went stale with --label-name")
+	fs.StringVar(&policy.Label, "label-name", stale.DefaultLabel, "Label marking warned PRs")
+	fs.StringVar(&closeAfter, "close-after", "", "Close labelled PRs still inactive this long after the warning (e.g. 7d)")
+	fs.StringVar(&message, "message", "", "Warning comment (default: explains when the PR will be closed)")
+	fs.BoolVar(&apply, "apply", false, "Make the changes; without it shippr only reports what it would do")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr stale --org <org> [--repo <repo>] [--older-than 30d] [--comment] [--label] [--close-after 7d] [--apply]")
+		fmt.Fprintln(os.Stderr, "       shippr stale [flags] <org/repo>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	repo, ok := scanTarget(fs, org, repo)
+	if !ok {
+		fs.Usage()
+		os.Exit(1)
+	}
+	var err error
+	if policy.OlderThan, err = query.ParseAge(olderThan); err != nil {
+		fatal(err)
+	}
+	if closeAfter != "" {
+		if policy.Grace, err = query.ParseAge(closeAfter); err != nil {
+			fatal(err)
+		}
+	}
+	if label && policy.Label == "" {
+		fatal(fmt.Errorf("--label needs a --label-name"))
+	}
+	if policy.Grace > 0 && !label {
+		// The grace period is timed from the label.
+		fatal(fmt.Errorf("--close-after needs --label"))
+	}
+	if message == "" {
+		message = warningMessage(policy)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+	if !apply {
+		dryrun.Enable(nil)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	prs, err := listOpenPRs(ctx, org, repo, repoLimit)
+	if err != nil {
+		fatal(err)
+	}
+	found := stale.Find(prs, policy, time.Now(), func(r gh.RepoPR) (time.Time, bool) {
+		at, ok, err := gh.LabeledAt(ctx, r.Repo, r.PR.Number, policy.Label)
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("✗ %s#%d: can't tell when it was labelled: %s", r.Repo, r.PR.Number, gh.Friendly(err))))
+		}
+		return at, ok
+	})
+	if len(found) == 0 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("No PRs without activity for %s", olderThan)))
+		return
+	}
+	groups := stale.ByAuthor(found)
+	printStaleReport(groups, comment, label)
+
+	s := staleSummary{warns: comment || label, labels: label}
+	for _, g := range groups {
+		for _, p := range g.PRs {
+			if err := ctx.Err(); err != nil {
+				printStaleSummary(s)
+				fatal(err)
+			}
+			s.add(p, actOnStale(ctx, p, policy, message, comment, label))
+		}
+	}
+	reportDryRun()
+	printStaleSummary(s)
+	if len(s.failed) > 0 {
+		os.Exit(exitError)
+	}
+}
+
+func warningMessage(p stale.Policy) string {
+	days := func(d time.Duration) string { return fmt.Sprintf("%d days", int(d.Hours()/24)) }
+	msg := fmt.Sprintf("This pull request has had no activity for %s.", days(p.OlderThan))
+	if p.Grace > 0 {
+		return msg + fmt.Sprintf(" It will be closed in %s unless it is updated.", days(p.Grace))
+	}
+	return msg + " Please update it, or close it if it is no longer needed."
+}
+
+// actOnStale carries out p's action. A warning comments and labels as asked
+// and does nothing without either.
+func actOnStale(ctx context.Context, p stale.PR, policy stale.Policy, message string, comment, label bool) error {
+	switch p.Action {
+	case stale.Warn:
+		if comment {
+			if err := gh.Comment(ctx, p.Repo, p.PR.Number, message); err != nil {
+				return err
+			}
+		}
+		if label {
+			return gh.AddLabels(ctx, p.Repo, p.PR.Number, policy.Label)
+		}
+	case stale.Close:
+		return gh.ClosePR(ctx, p.Repo, p.PR.Number)
+	case stale.Revive:
+		if label {
+			return gh.RemoveLabels(ctx, p.Repo, p.PR.Number, policy.Label)
+		}
+	}
+	return nil
+}
+
+func printStaleReport(groups []stale.Group, comment, label bool) {
+	author := ""
+	for _, g := range groups {
+		if g.Author != author {
+			author = g.Author
+			fmt.Println(titleStyle.Render(author))
+		}
+		fmt.Println("  " + branchStyle.Render(g.Repo))
+		for _, p := range g.PRs {
+			fmt.Printf("    %s %s %s %s\n",
+				prNumberStyle.Render(fmt.Sprintf("#%d", p.PR.Number)),
+				p.PR.Title,
+				infoStyle.Render(relativeAge(p.PR.UpdatedAt, time.Now())+" idle"),
+				staleActionLabel(p.Action, comment, label))
+		}
+	}
+	fmt.Println()
+}
+
+func staleActionLabel(a stale.Action, comment, label bool) string {
+	switch a {
+	case stale.Close:
+		return errorStyle.Render("→ close")
+	case stale.Wait:
+		return infoStyle.Render("(warned, in grace period)")
+	case stale.Revive:
+		if label {
+			return successStyle.Render("→ unlabel (active again)")
+		}
+		return successStyle.Render("(active again)")
+	}
+	if !comment && !label {
+		return accentStyle.Render("stale")
+	}
+	return accentStyle.Render("→ warn")
+}
+
+// staleSummary counts what a run did. warns is false when neither
+// --comment nor --label was given, so stale PRs are only reported; labels
+// is false without --label, which leaves labels on revived PRs.
+type staleSummary struct {
+	warns, labels                          bool
+	warned, closed, revived, waiting, left int
+	failed                                 []string
+}
+
+func (s *staleSummary) add(p stale.PR, err error) {
+	if err != nil {
+		s.failed = append(s.failed, fmt.Sprintf("%s#%d: %s", p.Repo, p.PR.Number, gh.Friendly(err)))
+		return
+	}
+	switch {
+	case p.Action == stale.Warn && !s.warns:
+		s.left++
+	case p.Action == stale.Warn:
+		s.warned++
+	case p.Action == stale.Close:
+		s.closed++
+	case p.Action == stale.Revive && s.labels:
+		s.revived++
+	case p.Action == stale.Revive:
+		// Shown in the report; without --label the label stays.
+	default:
+		s.waiting++
+	}
+}
+
+func printStaleSummary(s staleSummary) {
+	applied := !dryrun.Enabled()
+	warn, closing, unlabel := "Would warn", "would close", "would unlabel"
+	if applied {
+		warn, closing, unlabel = "Warned", "closed", "unlabelled"
+	}
+	var parts []string
+	if s.warned > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d stale PR(s)", warn, s.warned))
+	}
+	if s.closed > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d", closing, s.closed))
+	}
+	if s.revived > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d active again", unlabel, s.revived))
+	}
+	if s.waiting > 0 {
+		parts = append(parts, fmt.Sprintf("%d waiting out the grace period", s.waiting))
+	}
+	if s.left > 0 {
+		parts = append(parts, fmt.Sprintf("%d reported only (warn with --comment or --label)", s.left))
+	}
+	if len(parts) > 0 {
+		line := strings.Join(parts, "; ")
+		fmt.Println(successStyle.Render(strings.ToUpper(line[:1]) + line[1:]))
+	}
+	if !applied && s.warned+s.closed+s.revived > 0 {
+		fmt.Println(infoStyle.Render("Nothing was changed; re-run with --apply to make these changes."))
+	}
+	for _, f := range s.failed {
+		fmt.Println(errorStyle.Render("✗ " + f))
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+
+	"git-shippr/internal/theme"
+
+	list "github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var (
+	palette theme.Palette
+
+	primary   lipgloss.TerminalColor
+	secondary lipgloss.TerminalColor
+	accent    lipgloss.TerminalColor
+	text      lipgloss.TerminalColor
+
+	titleStyle     lipgloss.Style
+	infoStyle      lipgloss.Style
+	successStyle   lipgloss.Style
+	errorStyle     lipgloss.Style
+	branchStyle    lipgloss.Style
+	accentStyle    lipgloss.Style
+	prNumberStyle  lipgloss.Style
+	highlightStyle lipgloss.Style
+	borderStyle    lipgloss.Style
+)
+
+func init() {
+	p, _ := theme.Builtin(theme.Dark)
+	applyPalette(p)
+}
+
+// noColorRequested reports whether color output was disabled by flag or by
+// the NO_COLOR convention (https://no-color.org).
+func noColorRequested(flagSet bool) bool {
+	return flagSet || os.Getenv("NO_COLOR") != ""
+}
+
+// setupTheme resolves the configured theme and applies it to all styles.
+// With noColor the color profile is forced to plain ASCII so neither our
+// styles nor the bubbles defaults emit color sequences.
+func setupTheme(name string, custom map[string]theme.Palette, noColor bool) error {
+	if noColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		p, _ := theme.Builtin(theme.Monochrome)
+		applyPalette(p)
+		return nil
+	}
+	p, err := theme.Resolve(name, custom, lipgloss.HasDarkBackground())
+	if err != nil {
+		return err
+	}
+	applyPalette(p)
+	return nil
+}
+
+func color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+func applyPalette(p theme.Palette) {
+	palette = p
+	primary = color(p.Primary)
+	secondary = color(p.Secondary)
+	accent = color(p.Accent)
+	text = color(p.Text)
+
+	titleStyle = lipgloss.NewStyle().
+		Foreground(primary).
+		Bold(true)
+
+	infoStyle = lipgloss.NewStyle().
+		Foreground(text)
+
+	successStyle = lipgloss.NewStyle().
+		Foreground(secondary).
+		Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(color(p.Error)).
+		Bold(true)
+
+	branchStyle = lipgloss.NewStyle().
+		Foreground(accent)
+
+	accentStyle = lipgloss.NewStyle().
+		Foreground(accent)
+
+	prNumberStyle = lipgloss.NewStyle().
+		Foreground(primary).
+		Bold(true)
+
+	highlightStyle = lipgloss.NewStyle().
+		Foreground(color(p.HighlightFg)).
+		Background(color(p.HighlightBg)).
+		Bold(true).
+		Padding(0, 1)
+
+	borderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primary).
+		Padding(1)
+
+	if p.IsMonochrome() {
+		// Without colors, reverse video is the only way to make keys stand out.
+		highlightStyle = highlightStyle.Reverse(true)
+	}
+}
+
+// newListDelegate returns the default item delegate restyled for the active
+// palette so selection stays visible on any background.
+func newListDelegate() list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	if palette.IsMonochrome() {
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.UnsetForeground().UnsetBorderForeground().Bold(true)
+		d.Styles.SelectedDesc = d.Styles.SelectedDesc.UnsetForeground().UnsetBorderForeground()
+		d.Styles.NormalTitle = d.Styles.NormalTitle.UnsetForeground()
+		d.Styles.NormalDesc = d.Styles.NormalDesc.UnsetForeground().Faint(true)
+		d.Styles.DimmedTitle = d.Styles.DimmedTitle.UnsetForeground().Faint(true)
+		d.Styles.DimmedDesc = d.Styles.DimmedDesc.UnsetForeground().Faint(true)
+		return d
+	}
+	d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(text)
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(primary).BorderForeground(primary)
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(accent).BorderForeground(primary)
+	return d
+}
+
+func styleList(l *list.Model) {
+	if palette.IsMonochrome() {
+		l.Styles.Title = l.Styles.Title.UnsetBackground().UnsetForeground().Bold(true).Reverse(true)
+		return
+	}
+	l.Styles.Title = l.Styles.Title.
+		Background(color(palette.HighlightBg)).
+		Foreground(color(palette.HighlightFg))
+	l.Styles.FilterPrompt = l.Styles.FilterPrompt.Foreground(accent)
+	l.Styles.FilterCursor = l.Styles.FilterCursor.Foreground(primary)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"git-shippr/internal/config"
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+
+	list "github.com/charmbracelet/bubbles/list"
+)
+
+// defaultViews are used when the config file defines none.
+var defaultViews = []config.View{
+	{Name: "Ready to merge", Query: "checks:passing review:approved -is:draft -is:conflict"},
+	{Name: "Needs my review", Query: "review-requested:me"},
+	{Name: "Mine", Query: "author:me"},
+	{Name: "Dependabot", Query: "author:dependabot"},
+}
+
+// pickerViews returns the views cycled with "v"; the first is always the
+// unfiltered list.
+func pickerViews(cfg *config.Config) []config.View {
+	views := defaultViews
+	if cfg != nil && len(cfg.Views) > 0 {
+		views = cfg.Views
+	}
+	return append([]config.View{{Name: "All"}}, views...)
+}
+
+// prFilter evaluates the query language for the picker's filter box. The
+// bubbles list hands the filter only FilterValue strings, so items are
+// looked up by that (unique) key.
+type prFilter struct {
+	mu    sync.RWMutex
+	byKey map[string]gh.RepoPR
+	me    string
+}
+
+func newPRFilter() *prFilter {
+	return &prFilter{byKey: map[string]gh.RepoPR{}}
+}
+
+func (f *prFilter) setItems(items []list.Item) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.byKey = make(map[string]gh.RepoPR, len(items))
+	for _, it := range items {
+		if p, ok := it.(prItem); ok {
+			f.byKey[p.FilterValue()] = gh.RepoPR{Repo: p.repo, PR: p.PR}
+		}
+	}
+}
+
+func (f *prFilter) setMe(login string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.me = login
+}
+
+// filter applies structured terms first and fuzzy-matches any remaining free
+// text. Unparseable input falls back to plain fuzzy matching.
+func (f *prFilter) filter(term string, targets []string) []list.Rank {
+	q, err := query.Parse(term)
+	if err != nil {
+		return list.DefaultFilter(term, targets)
+	}
+	f.mu.RLock()
+	env := query.Env{Me: f.me, Now: time.Now()}
+	var idx []int
+	var subset []string
+	for i, t := range targets {
+		pr, ok := f.byKey[t]
+		if !ok || !q.MatchTerms(pr, env) {
+			continue
+		}
+		idx = append(idx, i)
+		subset = append(subset, t)
+	}
+	f.mu.RUnlock()
+
+	if q.Text() == "" {
+		ranks := make([]list.Rank, len(idx))
+		for i, orig := range idx {
+			ranks[i] = list.Rank{Index: orig}
+		}
+		return ranks
+	}
+	ranks := list.DefaultFilter(q.Text(), subset)
+	for i := range ranks {
+		ranks[i].Index = idx[ranks[i].Index]
+	}
+	return ranks
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"git-shippr/internal/config"
+	"git-shippr/internal/dryrun"
+	"git-shippr/internal/gh"
+	"git-shippr/internal/watch"
+)
+
+func watchCmd(args []string) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var org, repo, merge, label, logFormat string
+	var interval time.Duration
+	var deleteBranch bool
+	fs.StringVar(&org, "org", "", "GitHub organization or user")
+	fs.StringVar(&repo, "repo", "", "Repository to watch")
+	fs.DurationVar(&interval, "interval", 2*time.Minute, "How often to poll open PRs")
+	fs.StringVar(&merge, "merge", "", "Merge PRs once they are approved, green and mergeable, with this strategy: squash, rebase or merge")
+	fs.BoolVar(&deleteBranch, "delete-branch", true, "Delete head branches after --merge")
+	fs.StringVar(&label, "label-conflicts", "", "Add this label to PRs that run into merge conflicts")
+	fs.StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Print(getLogo() + "\n")
+		fmt.Fprintln(os.Stderr, "Usage: shippr watch --org <org> --repo <repo> [--interval 2m] [--merge squash] [--label-conflicts conflicts] [--log-format json]")
+		fmt.Fprintln(os.Stderr, "       shippr watch [flags] <org/repo>")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	repo, ok := scanTarget(fs, org, repo)
+	if !ok || repo == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if interval < 10*time.Second {
+		fatal(fmt.Errorf("--interval %s is too short: poll at most every 10s", interval))
+	}
+	switch merge = strings.TrimPrefix(merge, "--"); merge {
+	case "", "squash", "rebase", "merge":
+	default:
+		fatal(fmt.Errorf("invalid --merge %q: want squash, rebase or merge", merge))
+	}
+	log, err := newLogger(logFormat)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := opts.setup(); err != nil {
+		fatal(err)
+	}
+	w := &watcher{log: log, merge: merge, deleteBranch: deleteBranch, label: label}
+	if err := w.run(repo, interval); err != nil {
+		fatal(err)
+	}
+}
+
+func newLogger(format string) (*slog.Logger, error) {
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, nil)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, nil)), nil
+	}
+	return nil, fmt.Errorf("invalid --log-format %q: want text or json", format)
+}
+
+// watcher polls one repository, logging each event and acting on those it
+// was asked to handle.
+type watcher struct {
+	log          *slog.Logger
+	state        *watch.State
+	merge        string
+	deleteBranch bool
+	label        string
+}
+
+// run polls until SIGINT or SIGTERM. A merge already sent to GitHub is
+// waited for, and events not yet handled are reported again on restart.
+func (w *watcher) run(repo string, interval time.Duration) error {
+	ctx, stop := signalContext()
+	defer stop()
+	dir, err := config.StateDir()
+	if err != nil {
+		return err
+	}
+	if w.state, err = watch.Load(dir, repo); err != nil {
+		return err
+	}
+	unlock, err := w.state.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if dryrun.Enabled() {
+		dryrun.Enable(os.Stdout)
+	}
+
+	w.log.Info("watching", "repo", repo, "interval", interval, "merge", w.merge,
+		"label_conflicts", w.label, "dry_run", dryrun.Enabled())
+	for {
+		w.poll(ctx)
+		select {
+		case <-ctx.Done():
+			w.log.Info("stopped", "repo", repo)
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (w *watcher) poll(ctx context.Context) {
+	repo := w.state.Repo
+	prs, err := gh.ListPRs(ctx, repo, gh.ListOptions{State: gh.StateOpen, Limit: gh.LimitAll})
+	if err != nil {
+		if ctx.Err() == nil {
+			w.log.Warn("poll failed", "repo", repo, "error", gh.Friendly(err))
+		}
+		return
+	}
+	events := w.state.Observe(prs, time.Now())
+	for i, ev := range events {
+		if ctx.Err() != nil {
+			for _, rest := range events[i:] {
+				w.state.Retry(rest)
+			}
+			break
+		}
+		w.log.Info("pr event", "event", string(ev.Kind), "repo", repo, "pr", ev.PR.Number,
+			"title", ev.PR.Title, "author", ev.PR.Author.Login, "first_seen", ev.First)
+		if err := w.act(ctx, ev); err != nil {
+			w.log.Error("action failed", "event", string(ev.Kind), "repo", repo, "pr", ev.PR.Number, "error", gh.Friendly(err))
+			// Transient failures are retried on the next poll; others would
+			// only fail again.
+			if ctx.Err() != nil || errors.Is(err, gh.ErrNetwork) || errors.Is(err, gh.ErrRateLimited) {
+				w.state.Retry(ev)
+			}
+		}
+	}
+	// A dry run leaves the state alone so the real run still sees every event.
+	if dryrun.Enabled() {
+		return
+	}
+	if err := w.state.Save(); err != nil {
+		w.log.Error("save state failed", "repo", repo, "error", err)
+	}
+}
+
+// act carries out the action configured for ev, if any.
+func (w *watcher) act(ctx context.Context, ev watch.Event) error {
+	repo, n := ev.Repo, ev.PR.Number
+	switch {
+	// A PR found ready when the watcher starts, or first sees it, is left
+	// alone: --merge acts on PRs that become ready while watched.
+	case ev.Kind == watch.Ready && w.merge != "" && !ev.First:
+		if err := gh.MergePR(ctx, repo, n, "--"+w.merge, w.deleteBranch); err != nil {
+			return err
+		}
+		w.log.Info("merged", "repo", repo, "pr", n, "strategy", w.merge, "delete_branch", w.deleteBranch)
+	case ev.Kind == watch.Conflicting && w.label != "" && !prHasLabel(ev.PR, w.label):
+		if err := gh.AddLabels(ctx, repo, n, w.label); err != nil {
+			return err
+		}
+		w.log.Info("labelled", "repo", repo, "pr", n, "label", w.label)
+	}
+	return nil
+}
+
+func prHasLabel(pr gh.PR, name string) bool {
+	for _, l := range pr.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+// Package audit keeps an append-only JSONL record of every change shippr
+// makes on GitHub, so "who merged this and how" can be answered later.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the log.
+const (
+	Merge          = "merge"
+	Approve        = "approve"
+	RequestChanges = "request-changes"
+	RequestReview  = "request-review"
+	Label          = "label"
+	Unlabel        = "unlabel"
+	Comment        = "comment"
+	UpdateBranch   = "update-branch"
+	EditBase       = "edit-base"
+	DeleteBranch   = "delete-branch"
+	RestoreBranch  = "restore-branch"
+	CreatePR       = "create-pr"
+	Close          = "close"
+	Reopen         = "reopen"
+	Enqueue        = "enqueue"
+	Dequeue        = "dequeue"
+)
+
+// Outcomes.
+const (
+	Success = "success"
+	Failure = "failure"
+	// Cancelled actions were stopped by the user before gh ran.
+	Cancelled = "cancelled"
+)
+
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor,omitempty"`
+	Action       string    `json:"action"`
+	Repo         string    `json:"repo"`
+	PR           int       `json:"pr,omitempty"`
+	HeadSHA      string    `json:"head_sha,omitempty"`
+	Branch       string    `json:"branch,omitempty"`
+	Strategy     string    `json:"strategy,omitempty"`
+	DeleteBranch bool      `json:"delete_branch,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	Outcome      string    `json:"outcome"`
+	Error        string    `json:"error,omitempty"`
+}
+
+var (
+	mu   sync.Mutex
+	path string
+)
+
+// Enable starts recording to the JSONL file at p.
+func Enable(p string) {
+	mu.Lock()
+	defer mu.Unlock()
+	path = p
+}
+
+// Disable stops recording.
+func Disable() { Enable("") }
+
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return path != ""
+}
+
+// Append writes e as one line. Each line is written with a single
+// O_APPEND write so concurrent shippr processes don't interleave entries.
+func Append(e Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if path == "" {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	return nil
+}
+
+// Filter selects entries; zero fields match everything.
+type Filter struct {
+	Repo   string
+	PR     int
+	Action string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case f.Repo != "" && !strings.EqualFold(f.Repo, e.Repo):
+		return false
+	case f.PR != 0 && f.PR != e.PR:
+		return false
+	case f.Action != "" && f.Action != e.Action:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// DeletedBranch finds the most recent record of PR number's head branch
+// being deleted in repo, either by a merge with --delete-branch or by an
+// explicit branch deletion (as in stack merges, which pass branch). It
+// reports false if entries hold no such record with a commit SHA.
+func DeletedBranch(entries []Entry, repo string, number int, branch string) (Entry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !strings.EqualFold(e.Repo, repo) || e.HeadSHA == "" || e.Outcome != Success {
+			continue
+		}
+		switch {
+		case e.Action == Merge && e.PR == number && e.DeleteBranch && e.Branch != "":
+			return e, true
+		case e.Action == DeleteBranch && branch != "" && e.Branch == branch:
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Read returns the entries in the log at p matching f, oldest first. A
+// missing log is empty; malformed lines are skipped.
+func Read(p string, f Filter) ([]Entry, error) {
+	file, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	defer file.Close()
+	var out []Entry
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		if f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return out, nil
+}
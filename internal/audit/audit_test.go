@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nested", "audit.jsonl")
+	Enable(p)
+	defer Disable()
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: day, Action: Merge, Repo: "acme/web", PR: 1, Strategy: "--squash", DeleteBranch: true, Outcome: Success},
+		{Time: day.Add(24 * time.Hour), Action: Approve, Repo: "acme/web", PR: 2, Outcome: Success},
+		{Time: day.Add(48 * time.Hour), Action: Merge, Repo: "acme/api", PR: 3, Outcome: Failure, Error: "not mergeable"},
+	}
+	for _, e := range entries {
+		if err := Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A torn or foreign line must not break reading.
+	f, _ := os.OpenFile(p, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.WriteString("{not json\n")
+	_ = f.Close()
+
+	all, err := Read(p, Filter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("read all: %v %v", all, err)
+	}
+	merges, _ := Read(p, Filter{Action: Merge})
+	if len(merges) != 2 || merges[1].Error != "not mergeable" {
+		t.Fatalf("unexpected merges %+v", merges)
+	}
+	web, _ := Read(p, Filter{Repo: "ACME/web", Since: day.Add(time.Hour)})
+	if len(web) != 1 || web[0].PR != 2 {
+		t.Fatalf("unexpected repo/since filter result %+v", web)
+	}
+	until, _ := Read(p, Filter{Until: day.Add(24 * time.Hour)})
+	if len(until) != 1 || until[0].PR != 1 {
+		t.Fatalf("until should be exclusive, got %+v", until)
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	got, err := Read(filepath.Join(t.TempDir(), "none.jsonl"), Filter{})
+	if err != nil || got != nil {
+		t.Fatalf("expected empty result, got %v %v", got, err)
+	}
+}
+
+func TestDeletedBranch(t *testing.T) {
+	entries := []Entry{
+		{Action: Merge, Repo: "acme/web", PR: 1, Branch: "feat-a", HeadSHA: "aaa", DeleteBranch: true, Outcome: Success},
+		{Action: DeleteBranch, Repo: "acme/web", Branch: "feat-b", HeadSHA: "bbb", Outcome: Success},
+		{Action: Merge, Repo: "acme/web", PR: 1, Branch: "feat-a", HeadSHA: "ccc", DeleteBranch: true, Outcome: Failure},
+		{Action: Merge, Repo: "acme/web", PR: 3, HeadSHA: "ddd", Outcome: Success},
+	}
+	if e, ok := DeletedBranch(entries, "acme/web", 1, ""); !ok || e.HeadSHA != "aaa" {
+		t.Fatalf("expected successful merge record, got %+v %v", e, ok)
+	}
+	if e, ok := DeletedBranch(entries, "acme/web", 2, "feat-b"); !ok || e.HeadSHA != "bbb" {
+		t.Fatalf("expected branch deletion record, got %+v %v", e, ok)
+	}
+	if _, ok := DeletedBranch(entries, "acme/web", 3, ""); ok {
+		t.Fatalf("merge without --delete-branch must not match")
+	}
+}
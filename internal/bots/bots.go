@@ -0,0 +1,228 @@
+// Package bots recognises dependency update PRs opened by bots such as
+// Dependabot and Renovate and groups them by dependency and update type.
+package bots
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+)
+
+// Update is how far a dependency update moves, by semantic versioning.
+type Update int
+
+const (
+	Patch Update = iota
+	// Minor also covers updates known to be minor or patch, e.g. Renovate
+	// titles that only give the new version.
+	Minor
+	Major
+	// Unknown is a title shippr can't parse, e.g. a grouped update. It is
+	// handled like a major update.
+	Unknown
+)
+
+func (u Update) String() string {
+	return [...]string{"patch", "minor", "major", "unknown"}[u]
+}
+
+// ParseUpdate parses "patch", "minor" or "major".
+func ParseUpdate(s string) (Update, bool) {
+	for u := Patch; u <= Major; u++ {
+		if strings.EqualFold(s, u.String()) {
+			return u, true
+		}
+	}
+	return 0, false
+}
+
+// Bump is a dependency update parsed from a PR title.
+type Bump struct {
+	Dependency string
+	// From is empty when the title only names the new version.
+	From, To string
+	Update   Update
+}
+
+var (
+	// Dependabot: "Bump lodash from 4.17.20 to 4.17.21 in /web", optionally
+	// after a conventional commit prefix such as "build(deps): ".
+	dependabotRe = regexp.MustCompile(`(?i)^(?:[a-z-]+(?:\([^)]*\))?!?:\s*)?bump (\S+) from v?(\S+) to v?(\S+)`)
+	// Renovate: "Update dependency react to v18", "chore(deps): update
+	// module github.com/x/y to v1.5.0", "Update actions/checkout action to v4".
+	renovateRe = regexp.MustCompile(`(?i)^(?:[a-z-]+(?:\([^)]*\))?!?:\s*)?update (?:(?:dependency|module|rust crate|docker tag|helm release|gem|package) )?(\S+)(?: \S+)?? to v?(\S+)`)
+	// The version change in a Renovate PR body's table: "`8.56.0` -> `8.57.0`".
+	renovateBodyRe = regexp.MustCompile("`[\\^~=v]*([^`]+)` -> `[\\^~=v]*([^`]+)`")
+)
+
+// Parse extracts the update from a bot PR's title. ok is false for titles
+// that don't describe a single dependency update.
+func Parse(title string) (b Bump, ok bool) {
+	title = strings.TrimSpace(title)
+	if m := dependabotRe.FindStringSubmatch(title); m != nil {
+		b = Bump{Dependency: m[1], From: m[2], To: m[3]}
+		b.Update = compare(b.From, b.To)
+		return b, true
+	}
+	if m := renovateRe.FindStringSubmatch(title); m != nil {
+		b = Bump{Dependency: m[1], To: m[2]}
+		// Renovate names only the new major ("to v18") for major updates
+		// and the full version otherwise.
+		switch n := len(versionParts(b.To)); {
+		case n == 0:
+			b.Update = Unknown
+		case n == 1:
+			b.Update = Major
+		default:
+			b.Update = Minor
+		}
+		return b, true
+	}
+	return Bump{}, false
+}
+
+// ParseDetails is Parse with the PR's body at hand. Renovate only names the
+// new version in titles; its PR body has the old one too, which tells
+// patch from minor updates.
+func ParseDetails(title, body string) (Bump, bool) {
+	b, ok := Parse(title)
+	if !ok || b.From != "" {
+		return b, ok
+	}
+	if m := renovateBodyRe.FindStringSubmatch(body); m != nil {
+		b.From = m[1]
+		b.Update = compare(b.From, b.To)
+	}
+	return b, true
+}
+
+// NeedsBody reports whether title is a bot update that ParseDetails can
+// only classify with the PR's body. A title naming just a new major is
+// major either way.
+func NeedsBody(title string) bool {
+	b, ok := Parse(title)
+	return ok && b.From == "" && b.Update != Major
+}
+
+// compare classifies an update from one version to another. On 0.x
+// versions a minor bump may break compatibility, so it counts as major.
+func compare(from, to string) Update {
+	f, t := versionParts(from), versionParts(to)
+	if len(f) == 0 || len(t) == 0 {
+		return Unknown
+	}
+	at := func(v []int, i int) int {
+		if i < len(v) {
+			return v[i]
+		}
+		return 0
+	}
+	switch {
+	case at(f, 0) != at(t, 0):
+		return Major
+	case at(f, 1) != at(t, 1) && at(f, 0) == 0:
+		return Major
+	case at(f, 1) != at(t, 1):
+		return Minor
+	}
+	return Patch
+}
+
+// versionParts returns the leading numeric components of a version such as
+// "v1.2.3-rc.1", or nil if it doesn't start with a number.
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSuffix(v, "."), "v")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if end == 0 {
+			break
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+		if end < len(s) {
+			break
+		}
+	}
+	return parts
+}
+
+// PR is a bot PR with its parsed update.
+type PR struct {
+	gh.RepoPR
+	Bump Bump
+}
+
+// Group is every bot PR updating one dependency by one kind of update,
+// across repositories.
+type Group struct {
+	Dependency string
+	Update     Update
+	PRs        []PR
+}
+
+// Collect picks the bot-authored PRs out of prs and groups them by
+// dependency and update type: patches first, then minors, majors and
+// unparsed titles, alphabetically within each. Titles that can't be parsed
+// are grouped by their own text. body, if not nil, returns the body of PRs
+// whose title needs it (see NeedsBody).
+func Collect(prs []gh.RepoPR, body func(gh.RepoPR) string) []Group {
+	var groups []Group
+	index := map[Bump]int{}
+	for _, r := range prs {
+		if !query.IsBot(r.PR.Author) {
+			continue
+		}
+		text := ""
+		if body != nil && NeedsBody(r.PR.Title) {
+			text = body(r)
+		}
+		b, ok := ParseDetails(r.PR.Title, text)
+		if !ok {
+			b = Bump{Dependency: r.PR.Title, Update: Unknown}
+		}
+		key := Bump{Dependency: b.Dependency, Update: b.Update}
+		i, seen := index[key]
+		if !seen {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Dependency: b.Dependency, Update: b.Update})
+		}
+		groups[i].PRs = append(groups[i].PRs, PR{RepoPR: r, Bump: b})
+	}
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(a.Update, b.Update), cmp.Compare(strings.ToLower(a.Dependency), strings.ToLower(b.Dependency)))
+	})
+	return groups
+}
+
+// Ready reports whether a PR can be merged without a closer look: not a
+// draft, no conflicts and every check passing. reason says why not.
+func Ready(pr gh.PR) (ok bool, reason string) {
+	switch {
+	case pr.IsDraft:
+		return false, "draft"
+	case pr.Mergeable == "CONFLICTING":
+		return false, "conflicts"
+	}
+	switch gh.CheckState(pr.StatusCheckRollup) {
+	case gh.ChecksFailing:
+		return false, "checks failing"
+	case gh.ChecksPending:
+		return false, "checks pending"
+	case "":
+		return false, "no checks"
+	}
+	return true, ""
+}
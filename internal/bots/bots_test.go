@@ -0,0 +1,112 @@
+package bots
+
+import (
+	"testing"
+
+	"git-shippr/internal/gh"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title, dep, from, to string
+		update               Update
+	}{
+		{"Bump lodash from 4.17.20 to 4.17.21", "lodash", "4.17.20", "4.17.21", Patch},
+		{"Bump lodash from 4.17.21 to 4.18.0 in /web", "lodash", "4.17.21", "4.18.0", Minor},
+		{"build(deps): bump github.com/spf13/cobra from 1.7.0 to 2.0.0", "github.com/spf13/cobra", "1.7.0", "2.0.0", Major},
+		{"chore(deps-dev): bump @types/node from 20.1.0 to 20.1.4 in /frontend", "@types/node", "20.1.0", "20.1.4", Patch},
+		{"Bump actions/checkout from v3 to v4", "actions/checkout", "3", "4", Major},
+		// A minor bump before 1.0 may break compatibility.
+		{"Bump golang.org/x/net from 0.17.0 to 0.18.0", "golang.org/x/net", "0.17.0", "0.18.0", Major},
+		{"Update dependency eslint to v8.57.0", "eslint", "", "8.57.0", Minor},
+		{"chore(deps): update module github.com/x/y to v1.5.0", "github.com/x/y", "", "1.5.0", Minor},
+		{"Update dependency react to v18", "react", "", "18", Major},
+		{"Update actions/setup-go action to v5", "actions/setup-go", "", "5", Major},
+	}
+	for _, tt := range tests {
+		b, ok := Parse(tt.title)
+		if !ok {
+			t.Errorf("Parse(%q) failed", tt.title)
+			continue
+		}
+		if b.Dependency != tt.dep || b.From != tt.from || b.To != tt.to || b.Update != tt.update {
+			t.Errorf("Parse(%q) = %+v, want %s %s→%s %s", tt.title, b, tt.dep, tt.from, tt.to, tt.update)
+		}
+	}
+	for _, title := range []string{"Bump the npm_and_yarn group across 1 directory with 3 updates", "Fix login redirect"} {
+		if b, ok := Parse(title); ok && b.Update != Unknown {
+			t.Errorf("Parse(%q) = %+v, want no single update", title, b)
+		}
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	body := "| Package | Change |\n|---|---|\n| [eslint](https://eslint.org) | [`^8.56.0` -> `^8.56.1`](https://renovatebot.com/diffs/npm/eslint/8.56.0/8.56.1) |"
+	b, ok := ParseDetails("Update dependency eslint to v8.56.1", body)
+	if !ok || b.From != "8.56.0" || b.Update != Patch {
+		t.Fatalf("ParseDetails = %+v, %v; want a patch from 8.56.0", b, ok)
+	}
+	if b, _ := ParseDetails("Bump lodash from 4.17.20 to 4.18.0", body); b.Update != Minor {
+		t.Fatalf("a title with both versions should win over the body, got %+v", b)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	row := func(repo string, n int, author, title string) gh.RepoPR {
+		return gh.RepoPR{Repo: repo, PR: gh.PR{Number: n, Title: title, Author: gh.Actor{Login: author}}}
+	}
+	groups := Collect([]gh.RepoPR{
+		row("o/a", 1, "app/dependabot", "Bump lodash from 4.17.20 to 4.17.21"),
+		row("o/b", 2, "app/dependabot", "Bump lodash from 4.17.19 to 4.17.21"),
+		row("o/a", 3, "app/renovate", "Update dependency react to v18"),
+		row("o/a", 4, "alice", "Bump lodash from 4.17.20 to 4.17.21"),
+		row("o/b", 7, "renovate[bot]", "Update dependency react to v18"),
+		row("o/c", 5, "app/dependabot", "Bump axios from 1.5.0 to 1.6.0"),
+		row("o/c", 6, "app/dependabot", "Bump lodash from 4.17.21 to 4.18.0"),
+		row("o/d", 8, "renovate[bot]", "Update dependency eslint to v8.56.1"),
+	}, func(r gh.RepoPR) string {
+		if r.PR.Number != 8 {
+			t.Errorf("body fetched for #%d, whose title says it all", r.PR.Number)
+		}
+		return "| eslint | `8.56.0` -> `8.56.1` |"
+	})
+	want := []struct {
+		dep    string
+		update Update
+		prs    int
+	}{
+		{"eslint", Patch, 1},
+		{"lodash", Patch, 2},
+		{"axios", Minor, 1},
+		{"lodash", Minor, 1},
+		{"react", Major, 2},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("Collect = %d groups, want %d: %+v", len(groups), len(want), groups)
+	}
+	for i, w := range want {
+		if g := groups[i]; g.Dependency != w.dep || g.Update != w.update || len(g.PRs) != w.prs {
+			t.Errorf("group %d = %s %s (%d PRs), want %s %s (%d)", i, g.Dependency, g.Update, len(g.PRs), w.dep, w.update, w.prs)
+		}
+	}
+}
+
+func TestReady(t *testing.T) {
+	passing := []gh.Check{{Status: "COMPLETED", Conclusion: "SUCCESS"}}
+	for _, tc := range []struct {
+		pr     gh.PR
+		reason string
+	}{
+		{gh.PR{StatusCheckRollup: passing}, ""},
+		{gh.PR{StatusCheckRollup: passing, IsDraft: true}, "draft"},
+		{gh.PR{StatusCheckRollup: passing, Mergeable: "CONFLICTING"}, "conflicts"},
+		{gh.PR{StatusCheckRollup: []gh.Check{{Status: "IN_PROGRESS"}}}, "checks pending"},
+		{gh.PR{StatusCheckRollup: []gh.Check{{Status: "COMPLETED", Conclusion: "FAILURE"}}}, "checks failing"},
+		{gh.PR{}, "no checks"},
+	} {
+		ok, reason := Ready(tc.pr)
+		if ok != (tc.reason == "") || reason != tc.reason {
+			t.Errorf("Ready(%+v) = %v %q, want %q", tc.pr, ok, reason, tc.reason)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"git-shippr/internal/theme"
+)
+
+type Config struct {
+	Theme  string                   `json:"theme,omitempty"`
+	Themes map[string]theme.Palette `json:"themes,omitempty"`
+	Views  []View                   `json:"views,omitempty"`
+	// AuditLog is where actions are recorded; empty means
+	// <state dir>/audit.jsonl and "off" disables the log.
+	AuditLog string `json:"audit_log,omitempty"`
+	// CacheTTL bounds how old cached GitHub responses may be before they
+	// are ignored, e.g. "10m"; "0" disables the cache.
+	CacheTTL string `json:"cache_ttl,omitempty"`
+}
+
+// DefaultCacheTTL applies when cache_ttl is unset.
+const DefaultCacheTTL = 10 * time.Minute
+
+// View is a named PR picker query, e.g. {"name": "Ready to merge",
+// "query": "checks:passing review:approved -is:draft"}.
+type View struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Path returns the config file location: $SHIPPR_CONFIG if set, otherwise
+// shippr/config.json under the user config directory.
+func Path() (string, error) {
+	if p := os.Getenv("SHIPPR_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shippr", "config.json"), nil
+}
+
+// PolicyPath returns where `shippr apply` reads its rules: $SHIPPR_POLICY
+// if set, otherwise policy.json next to the config file.
+func PolicyPath() (string, error) {
+	if p := os.Getenv("SHIPPR_POLICY"); p != "" {
+		return p, nil
+	}
+	cfg, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfg), "policy.json"), nil
+}
+
+// StateDir returns where shippr keeps persistent state (queues, logs):
+// $XDG_STATE_HOME/shippr, falling back to ~/.local/state/shippr.
+func StateDir() (string, error) {
+	if d := os.Getenv("XDG_STATE_HOME"); d != "" {
+		return filepath.Join(d, "shippr"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "shippr"), nil
+}
+
+// AuditLogPath resolves where the audit log lives. It returns "" when the
+// log is disabled.
+func (c *Config) AuditLogPath() (string, error) {
+	switch c.AuditLog {
+	case "off":
+		return "", nil
+	case "":
+		dir, err := StateDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "audit.jsonl"), nil
+	}
+	return c.AuditLog, nil
+}
+
+// CacheDir returns where cached GitHub responses are kept:
+// shippr under the user cache directory.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shippr"), nil
+}
+
+// CacheMaxAge parses CacheTTL, falling back to DefaultCacheTTL.
+func (c *Config) CacheMaxAge() (time.Duration, error) {
+	if c.CacheTTL == "" {
+		return DefaultCacheTTL, nil
+	}
+	d, err := time.ParseDuration(c.CacheTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache_ttl %q: %w", c.CacheTTL, err)
+	}
+	return d, nil
+}
+
+// Load reads the config at path. A missing file yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,84 @@
+// Package dryrun lets shippr show what it would change without changing it:
+// mutating commands are recorded instead of executed.
+package dryrun
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	out     io.Writer
+	calls   []string
+)
+
+// Enable turns dry-run mode on. Each intercepted command is printed to w as
+// it happens; pass nil to only collect them (e.g. while a TUI owns the
+// terminal) and print Calls afterwards.
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled, out, calls = true, w, nil
+}
+
+// Disable turns dry-run mode off and forgets recorded calls.
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled, out, calls = false, nil, nil
+}
+
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Intercept records the command if dry-run mode is on and reports whether
+// it did; callers must then skip running it.
+func Intercept(name string, args ...string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return false
+	}
+	line := Format(name, args...)
+	calls = append(calls, line)
+	if out != nil {
+		fmt.Fprintf(out, "[dry-run] %s\n", line)
+	}
+	return true
+}
+
+// Calls returns the commands recorded so far, oldest first.
+func Calls() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]string(nil), calls...)
+}
+
+// Format renders a command the way it could be pasted into a POSIX shell.
+func Format(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, quote(name))
+	for _, a := range args {
+		parts = append(parts, quote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,32 @@
+package dryrun
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatQuotesShellSpecials(t *testing.T) {
+	got := Format("gh", "pr", "review", "12", "--body", "it's not ready", "-f", "reviewers[]=octocat", "--label", "#urgent")
+	want := `gh pr review 12 --body 'it'\''s not ready' -f 'reviewers[]=octocat' --label '#urgent'`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	if Intercept("gh", "pr", "merge", "1") {
+		t.Fatalf("should not intercept while disabled")
+	}
+	var buf bytes.Buffer
+	Enable(&buf)
+	defer Disable()
+	if !Intercept("gh", "pr", "merge", "1", "--squash") {
+		t.Fatalf("should intercept while enabled")
+	}
+	if calls := Calls(); len(calls) != 1 || calls[0] != "gh pr merge 1 --squash" {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if buf.String() != "[dry-run] gh pr merge 1 --squash\n" {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,133 @@
+package gh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The response cache keeps list and detail responses on disk so the TUI can
+// render the last known state immediately and refresh it in the background.
+// It is off until EnableCache is called.
+var (
+	cacheMu  sync.Mutex
+	cacheDir string
+	cacheTTL time.Duration
+)
+
+// EnableCache stores responses under dir. Cached data older than ttl is
+// ignored.
+func EnableCache(dir string, ttl time.Duration) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cacheDir, cacheTTL = dir, ttl
+}
+
+// DisableCache stops reading and writing the cache.
+func DisableCache() { EnableCache("", 0) }
+
+type cacheRecord struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// cachePath files entries per owner (repo or org) so a mutation can drop
+// everything cached for that repository at once.
+func cachePath(owner, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(cacheDir, strings.ReplaceAll(owner, "/", "__"), hex.EncodeToString(sum[:12])+".json")
+}
+
+// cacheGet decodes a cached response into v and returns when it was
+// fetched. It misses when caching is off or the entry is older than the TTL.
+func cacheGet(owner, key string, v any) (time.Time, bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cacheDir == "" {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(cachePath(owner, key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var rec cacheRecord
+	if json.Unmarshal(data, &rec) != nil || time.Since(rec.FetchedAt) > cacheTTL {
+		return time.Time{}, false
+	}
+	if json.Unmarshal(rec.Data, v) != nil {
+		return time.Time{}, false
+	}
+	return rec.FetchedAt, true
+}
+
+// cachePut stores raw response data. Failures are ignored: the cache is an
+// optimisation only.
+func cachePut(owner, key string, data []byte) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cacheDir == "" {
+		return
+	}
+	rec, err := json.Marshal(cacheRecord{FetchedAt: time.Now(), Data: data})
+	if err != nil {
+		return
+	}
+	// Titles and bodies of private repositories stay readable to the user only.
+	p := cachePath(owner, key)
+	if os.MkdirAll(filepath.Dir(p), 0o700) != nil {
+		return
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", p, os.Getpid())
+	if os.WriteFile(tmp, rec, 0o600) == nil {
+		_ = os.Rename(tmp, p)
+	}
+}
+
+// invalidateCache forgets everything cached for repo, e.g. after a merge.
+func invalidateCache(repo string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cacheDir == "" || repo == "" {
+		return
+	}
+	_ = os.RemoveAll(filepath.Join(cacheDir, strings.ReplaceAll(repo, "/", "__")))
+}
+
+func listKey(opts ListOptions) string {
+	return fmt.Sprintf("pr list state=%s limit=%d", opts.State, opts.Limit)
+}
+
+func detailsKey(number int) string { return fmt.Sprintf("pr view %d", number) }
+
+// Fingerprint is an ETag-like digest of a PR listing. Check and review
+// changes don't always bump updatedAt, so it covers every field.
+func Fingerprint(prs []PR) string {
+	data, _ := json.Marshal(prs)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// CachedPRs returns the last ListPRs result for repo and opts, if cached
+// within the TTL, and when it was fetched.
+func CachedPRs(repo string, opts ListOptions) ([]PR, time.Time, bool) {
+	var prs []PR
+	at, ok := cacheGet(repo, listKey(opts), &prs)
+	return prs, at, ok
+}
+
+// CachedPRDetails returns the last GetPRDetails result, if cached within
+// the TTL.
+func CachedPRDetails(repo string, number int) (*PRDetails, time.Time, bool) {
+	var d PRDetails
+	at, ok := cacheGet(repo, detailsKey(number), &d)
+	if !ok {
+		return nil, at, false
+	}
+	return &d, at, true
+}
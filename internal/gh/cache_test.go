@@ -0,0 +1,132 @@
+package gh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"git-shippr/internal/dryrun"
+)
+
+func TestCacheRoundTripAndTTL(t *testing.T) {
+	EnableCache(t.TempDir(), time.Hour)
+	defer DisableCache()
+
+	opts := ListOptions{State: StateOpen, Limit: 30}
+	if _, _, ok := CachedPRs("o/r", opts); ok {
+		t.Fatal("empty cache should miss")
+	}
+	cachePut("o/r", listKey(opts), []byte(`[{"number":7,"title":"x"}]`))
+	prs, at, ok := CachedPRs("o/r", opts)
+	if !ok || len(prs) != 1 || prs[0].Number != 7 {
+		t.Fatalf("cached prs = %+v, %v", prs, ok)
+	}
+	if time.Since(at) > time.Minute {
+		t.Fatalf("fetchedAt = %v", at)
+	}
+	if _, _, ok := CachedPRs("o/r", ListOptions{State: StateMerged, Limit: 30}); ok {
+		t.Fatal("a different query should miss")
+	}
+
+	EnableCache(cacheDir, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, _, ok := CachedPRs("o/r", opts); ok {
+		t.Fatal("entries older than the TTL should miss")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	DisableCache()
+	cachePut("o/r", "k", []byte(`1`))
+	var v int
+	if _, ok := cacheGet("o/r", "k", &v); ok {
+		t.Fatal("disabled cache should never hit")
+	}
+}
+
+func TestCacheFilesArePrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	EnableCache(t.TempDir(), time.Hour)
+	defer DisableCache()
+	cachePut("o/r", "k", []byte(`1`))
+	p := cachePath("o/r", "k")
+	for path, want := range map[string]os.FileMode{p: 0o600, filepath.Dir(p): 0o700} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMutationInvalidatesRepo(t *testing.T) {
+	dir := t.TempDir()
+	EnableCache(dir, time.Hour)
+	defer DisableCache()
+	cachePut("o/r", detailsKey(1), []byte(`{"number":1}`))
+	cachePut("o/other", detailsKey(1), []byte(`{"number":1}`))
+
+	invalidateCache("o/r")
+	if _, _, ok := CachedPRDetails("o/r", 1); ok {
+		t.Fatal("o/r should be invalidated")
+	}
+	if _, _, ok := CachedPRDetails("o/other", 1); !ok {
+		t.Fatal("other repos should be untouched")
+	}
+}
+
+func TestDryRunKeepsCache(t *testing.T) {
+	EnableCache(t.TempDir(), time.Hour)
+	defer DisableCache()
+	dryrun.Enable(nil)
+	defer dryrun.Disable()
+	cachePut("o/r", detailsKey(1), []byte(`{"number":1}`))
+	if err := ApprovePR(context.Background(), "o/r", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := CachedPRDetails("o/r", 1); !ok {
+		t.Fatal("a dry run changes nothing, so the cache should survive")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := []PR{{Number: 1, UpdatedAt: "2024-01-01T00:00:00Z"}}
+	b := []PR{{Number: 1, UpdatedAt: "2024-01-01T00:00:00Z", ReviewDecision: "APPROVED"}}
+	if Fingerprint(a) != Fingerprint(a) {
+		t.Fatal("fingerprint should be stable")
+	}
+	if Fingerprint(a) == Fingerprint(b) {
+		t.Fatal("fingerprint should change when any field changes")
+	}
+}
+
+func TestFreshOrgScanSkipsCache(t *testing.T) {
+	EnableCache(t.TempDir(), time.Hour)
+	defer DisableCache()
+	installFakeGH(t, func(args string) ([]byte, error) {
+		if strings.HasPrefix(args, "repo list") {
+			return []byte(`[{"name":"r","owner":{"login":"o"}}]`), nil
+		}
+		return []byte(`[{"number":2}]`), nil
+	})
+	opts := ListOptions{State: StateOpen, Limit: LimitAll}
+	cachePut("o/r", listKey(opts), []byte(`[{"number":1}]`))
+
+	res, err := ListOrgPRs(context.Background(), "o", 100, opts)
+	if err != nil || len(res.PRs) != 1 || res.PRs[0].PR.Number != 1 {
+		t.Fatalf("cached scan = %+v, %v; want the cached PR", res, err)
+	}
+	opts.Fresh = true
+	res, err = ListOrgPRs(context.Background(), "o", 100, opts)
+	if err != nil || len(res.PRs) != 1 || res.PRs[0].PR.Number != 2 {
+		t.Fatalf("fresh scan = %+v, %v; want the live PR", res, err)
+	}
+}
@@ -0,0 +1,231 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind says why a gh command failed.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindNotFound
+	KindUnauthorized
+	KindRateLimited
+	KindNotMergeable
+	// KindNetwork covers failures worth retrying: connection problems,
+	// timeouts and GitHub 5xx responses.
+	KindNetwork
+	// KindCancelled means the caller cancelled the context; it wraps
+	// context.Canceled rather than a sentinel of its own.
+	KindCancelled
+)
+
+// Sentinels for errors.Is; every *Error of the matching Kind is one.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrNotMergeable = errors.New("not mergeable")
+	ErrNetwork      = errors.New("network error")
+)
+
+var kindSentinels = map[Kind]error{
+	KindNotFound:     ErrNotFound,
+	KindUnauthorized: ErrUnauthorized,
+	KindRateLimited:  ErrRateLimited,
+	KindNotMergeable: ErrNotMergeable,
+	KindNetwork:      ErrNetwork,
+}
+
+func (k Kind) String() string {
+	if err, ok := kindSentinels[k]; ok {
+		return err.Error()
+	}
+	if k == KindCancelled {
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Error is a failed gh command, parsed from its output.
+type Error struct {
+	// Op is the command that failed, e.g. "gh pr merge".
+	Op   string
+	Kind Kind
+	// Status is the HTTP status gh reported, or 0.
+	Status int
+	// Message is GitHub's explanation without gh's decoration, e.g. "At
+	// least 2 approving reviews is required by reviewers with write access."
+	Message string
+	// Output is everything gh printed.
+	Output string
+	// Err is the underlying exec error.
+	Err error
+}
+
+// Error keeps the "<op> failed: <exec error>\n<output>" form callers have
+// always seen.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s failed: %v\n%s", e.Op, e.Err, e.Output)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) Is(target error) bool {
+	s, ok := kindSentinels[e.Kind]
+	return ok && s == target
+}
+
+var approvalsRe = regexp.MustCompile(`(?i)at least (\d+) approving reviews? (?:is|are) required`)
+
+// Friendly explains the failure in one line with what to do about it.
+func (e *Error) Friendly() string {
+	msg := strings.ToLower(e.Message + " " + e.Output)
+	switch e.Kind {
+	case KindUnauthorized:
+		if e.Status == 401 || strings.Contains(msg, "gh auth login") || strings.Contains(msg, "bad credentials") {
+			return "not authenticated — run gh auth login"
+		}
+		return "permission denied: " + e.Message
+	case KindNotFound:
+		return e.Message + " — check the repository and PR number, and that you have access"
+	case KindRateLimited:
+		return "GitHub rate limit reached — wait for the quota to reset and try again"
+	case KindNetwork:
+		return "could not reach GitHub (" + e.Message + ") — check your connection and try again"
+	case KindCancelled:
+		return e.Op + " cancelled"
+	case KindNotMergeable:
+		if m := approvalsRe.FindStringSubmatch(msg); m != nil {
+			if m[1] == "1" {
+				return "branch protection requires an approval"
+			}
+			return fmt.Sprintf("branch protection requires %s approvals", m[1])
+		}
+		switch {
+		case strings.Contains(msg, "required status check"):
+			return "required status checks have not passed yet"
+		case strings.Contains(msg, "merge conflict"), strings.Contains(msg, "cannot be cleanly created"):
+			return "the PR has merge conflicts — update or rebase the branch"
+		case strings.Contains(msg, "base branch was modified"):
+			return "the base branch moved — review the changes and merge again"
+		}
+		return "not mergeable: " + e.Message
+	}
+	return fmt.Sprintf("%s failed: %s", e.Op, e.Message)
+}
+
+// Friendly returns err's one-line explanation if it came from gh, and its
+// plain message otherwise.
+func Friendly(err error) string {
+	var ge *Error
+	switch {
+	case errors.As(err, &ge):
+		return ge.Friendly()
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+	return err.Error()
+}
+
+// Output patterns per kind, matched case-insensitively. Order matters: a
+// rate-limit 403 must not be reported as a permission problem.
+var errorPatterns = []struct {
+	kind     Kind
+	patterns []string
+}{
+	{KindRateLimited, []string{"rate limit", "http 429", "abuse detection"}},
+	{KindUnauthorized, []string{"http 401", "bad credentials", "gh auth login", "authentication required",
+		"resource not accessible", "must have admin rights", "http 403"}},
+	{KindNotFound, []string{"http 404", "could not resolve to", "not found", "no pull requests found"}},
+	{KindNotMergeable, []string{"not mergeable", "merge conflict", "base branch was modified", "approving review",
+		"required status check", "repository rule violations", "branch protection", "http 405"}},
+	{KindNetwork, []string{"http 500", "http 502", "http 503", "http 504", "connection refused", "connection reset",
+		"i/o timeout", "tls handshake timeout", "no such host", "network is unreachable", "error connecting to",
+		"unexpected eof", "something went wrong"}},
+}
+
+// kindOf classifies gh output. Cancellation is left unknown: it is the
+// caller's doing, not GitHub's.
+func kindOf(ctx context.Context, out []byte) Kind {
+	if ctx.Err() != nil {
+		return KindUnknown
+	}
+	s := strings.ToLower(string(out))
+	for _, p := range errorPatterns {
+		for _, pat := range p.patterns {
+			if strings.Contains(s, pat) {
+				return p.kind
+			}
+		}
+	}
+	return KindUnknown
+}
+
+var (
+	// "HTTP 404: Not Found (https://...)" from gh commands, "... (HTTP 404)"
+	// from gh api.
+	statusRe = regexp.MustCompile(`HTTP (\d{3})`)
+	// Decoration gh adds around GitHub's message.
+	prefixRe = regexp.MustCompile(`^(?:X |gh: |GraphQL: |HTTP \d{3}: )+`)
+	suffixRe = regexp.MustCompile(`\s*\((?:HTTP \d{3}|https?://[^)]*|[a-zA-Z.]+)\)$`)
+)
+
+// newError builds the *Error for op failing with err and output out. It
+// returns nil for a nil err. When ctx ended first, the error wraps ctx's
+// error instead of gh's exit status: a cancelled or timed out command
+// didn't fail on GitHub's side.
+func newError(ctx context.Context, op string, err error, out []byte) error {
+	if err == nil {
+		return nil
+	}
+	var done completedError
+	if errors.As(err, &done) {
+		ctx, err = context.WithoutCancel(ctx), done.error
+	}
+	e := &Error{Op: op, Kind: kindOf(ctx, out), Output: string(out), Err: err}
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.Canceled):
+		e.Kind, e.Err, e.Message = KindCancelled, ctxErr, "cancelled"
+		return e
+	case ctxErr != nil:
+		e.Err, e.Message = ctxErr, "timed out"
+		return e
+	}
+	if m := statusRe.FindStringSubmatch(e.Output); m != nil {
+		e.Status, _ = strconv.Atoi(m[1])
+	}
+	for _, line := range strings.Split(e.Output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			e.Message = suffixRe.ReplaceAllString(prefixRe.ReplaceAllString(line, ""), "")
+			break
+		}
+	}
+	if e.Message == "" {
+		e.Message = err.Error()
+	}
+	return e
+}
+
+const (
+	// maxTransientRetries bounds retries of network failures.
+	maxTransientRetries = 3
+	baseBackoff         = 500 * time.Millisecond
+	maxBackoff          = 8 * time.Second
+)
+
+// backoff returns the wait before retry attempt+1: exponential from
+// baseBackoff up to maxBackoff, jittered to between half and all of it so
+// concurrent retries spread out.
+func backoff(attempt int) time.Duration {
+	d := min(baseBackoff<<attempt, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,229 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewErrorClassifies(t *testing.T) {
+	tests := []struct {
+		out  string
+		want error
+	}{
+		{"GraphQL: Could not resolve to a Repository with the name 'o/nope'. (repository)", ErrNotFound},
+		{"HTTP 404: Not Found (https://api.github.com/repos/o/r/pulls/9)", ErrNotFound},
+		{"HTTP 401: Bad credentials (https://api.github.com/graphql)\nTry authenticating with:  gh auth login", ErrUnauthorized},
+		{"GraphQL: Resource not accessible by integration (mergePullRequest)", ErrUnauthorized},
+		{"HTTP 403: You have exceeded a secondary rate limit.", ErrRateLimited},
+		{"GraphQL: API rate limit exceeded for user ID 1.", ErrRateLimited},
+		{"X Pull request o/r#1 is not mergeable: the merge commit cannot be cleanly created.", ErrNotMergeable},
+		{"GraphQL: At least 2 approving reviews is required by reviewers with write access. (mergePullRequest)", ErrNotMergeable},
+		{`Post "https://api.github.com/graphql": dial tcp: lookup api.github.com: no such host`, ErrNetwork},
+		{"HTTP 502: Bad Gateway", ErrNetwork},
+	}
+	for _, tt := range tests {
+		err := newError(context.Background(), "gh pr merge", errExit, []byte(tt.out))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("newError(%q) = %v, want %v", tt.out, err, tt.want)
+		}
+		if !errors.Is(err, errExit) {
+			t.Errorf("newError(%q) should wrap the exec error", tt.out)
+		}
+		if want := "gh pr merge failed: exit status 1\n" + tt.out; err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	}
+	err := newError(context.Background(), "gh pr list", errExit, []byte("something odd"))
+	var ge *Error
+	if !errors.As(err, &ge) || ge.Kind != KindUnknown || errors.Is(err, ErrNetwork) {
+		t.Errorf("unrecognised output should be KindUnknown, got %#v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := newError(ctx, "gh pr list", errExit, []byte("i/o timeout")); errors.Is(err, ErrNetwork) {
+		t.Error("cancellation should not be reported as a network error")
+	}
+	if newError(context.Background(), "gh pr list", nil, nil) != nil {
+		t.Error("a nil error should stay nil")
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	tests := []struct {
+		out      string
+		status   int
+		message  string
+		friendly string
+	}{
+		{
+			"HTTP 401: Bad credentials (https://api.github.com/graphql)\nTry authenticating with:  gh auth login",
+			401, "Bad credentials", "not authenticated — run gh auth login",
+		},
+		{
+			"GraphQL: At least 2 approving reviews is required by reviewers with write access. (mergePullRequest)",
+			0, "At least 2 approving reviews is required by reviewers with write access.",
+			"branch protection requires 2 approvals",
+		},
+		{
+			"gh: Not Found (HTTP 404)", 404, "Not Found",
+			"Not Found — check the repository and PR number, and that you have access",
+		},
+		{
+			"X Pull request o/r#1 is not mergeable: the merge commit cannot be cleanly created.", 0,
+			"Pull request o/r#1 is not mergeable: the merge commit cannot be cleanly created.",
+			"the PR has merge conflicts — update or rebase the branch",
+		},
+		{
+			"GraphQL: Required status check \"ci\" is expected. (mergePullRequest)", 0,
+			`Required status check "ci" is expected.`, "required status checks have not passed yet",
+		},
+	}
+	for _, tt := range tests {
+		var ge *Error
+		if !errors.As(newError(context.Background(), "gh pr merge", errExit, []byte(tt.out)), &ge) {
+			t.Fatalf("newError(%q) is not an *Error", tt.out)
+		}
+		if ge.Status != tt.status || ge.Message != tt.message {
+			t.Errorf("%q: status %d message %q, want %d %q", tt.out, ge.Status, ge.Message, tt.status, tt.message)
+		}
+		if got := Friendly(fmt.Errorf("merge: %w", ge)); got != tt.friendly {
+			t.Errorf("%q: Friendly = %q, want %q", tt.out, got, tt.friendly)
+		}
+	}
+	if got := Friendly(errors.New("plain")); got != "plain" {
+		t.Errorf("Friendly(plain) = %q", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 6 {
+		d := min(baseBackoff<<attempt, maxBackoff)
+		for range 20 {
+			if got := backoff(attempt); got < d/2 || got > d {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempt, got, d/2, d)
+			}
+		}
+	}
+}
+
+func TestReadsRetryNetworkErrors(t *testing.T) {
+	n := 0
+	f := installFakeGH(t, func(string) ([]byte, error) {
+		if n++; n < 3 {
+			return []byte("read tcp 10.0.0.1:443: connection reset by peer"), errExit
+		}
+		return []byte(`{"number":5}`), nil
+	})
+	d, err := GetPRDetails(context.Background(), "o/r", 5)
+	if err != nil || d.Number != 5 {
+		t.Fatalf("GetPRDetails = %+v, %v", d, err)
+	}
+	if len(f.sleeps) != 2 || f.sleeps[1] < baseBackoff {
+		t.Fatalf("sleeps = %v, want two growing backoffs", f.sleeps)
+	}
+}
+
+func TestReadsFailFastOnPermanentErrors(t *testing.T) {
+	f := installFakeGH(t, func(string) ([]byte, error) {
+		return []byte("HTTP 404: Not Found"), errExit
+	})
+	_, err := ListPRs(context.Background(), "o/r", ListOptions{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if len(f.calls) != 1 {
+		t.Fatalf("calls = %v, want one", f.calls)
+	}
+}
+
+func TestMergeRetriesOnlyWhileStillOpen(t *testing.T) {
+	for _, tc := range []struct {
+		state      string
+		wantMerges int
+	}{
+		// The merge went through before the connection dropped.
+		{"MERGED", 1},
+		// Still open: safe to try again.
+		{"OPEN", 2},
+	} {
+		merges := 0
+		installFakeGH(t, func(args string) ([]byte, error) {
+			if strings.HasPrefix(args, "pr view") {
+				return []byte(tc.state), nil
+			}
+			if merges++; merges == 1 {
+				return []byte("Post \"https://api.github.com/graphql\": net/http: TLS handshake timeout"), errExit
+			}
+			return nil, nil
+		})
+		if err := MergePR(context.Background(), "o/r", 1, "--squash", false); err != nil {
+			t.Fatalf("%s: MergePR = %v", tc.state, err)
+		}
+		if merges != tc.wantMerges {
+			t.Fatalf("%s: merge ran %d times, want %d", tc.state, merges, tc.wantMerges)
+		}
+	}
+}
+
+func TestMergeDoesNotRetryWhenStateUnknown(t *testing.T) {
+	merges := 0
+	installFakeGH(t, func(args string) ([]byte, error) {
+		if strings.HasPrefix(args, "pr view") {
+			return []byte("HTTP 404: Not Found"), errExit
+		}
+		merges++
+		return []byte("HTTP 503: Service Unavailable"), errExit
+	})
+	err := MergePR(context.Background(), "o/r", 1, "--squash", false)
+	if !errors.Is(err, ErrNetwork) || merges != 1 {
+		t.Fatalf("MergePR = %v after %d merges, want one failed ErrNetwork attempt", err, merges)
+	}
+}
+
+func TestMutateSkipsGHOnceCancelled(t *testing.T) {
+	f := installFakeGH(t, func(string) ([]byte, error) { return nil, nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := MergePR(ctx, "o/r", 1, "--squash", false)
+	var ge *Error
+	if !errors.As(err, &ge) || ge.Kind != KindCancelled || !errors.Is(err, context.Canceled) {
+		t.Fatalf("MergePR = %#v, want a cancelled error", err)
+	}
+	if got := Friendly(err); got != "gh pr merge cancelled" {
+		t.Errorf("Friendly = %q", got)
+	}
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, "pr merge") {
+			t.Fatalf("gh ran %q after cancellation", c)
+		}
+	}
+}
+
+func TestMutateFinishesAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var during error
+	installFakeGH(t, func(args string) ([]byte, error) {
+		if !strings.HasPrefix(args, "pr merge") {
+			return nil, nil
+		}
+		// The user quits while gh is merging.
+		cancel()
+		return []byte("GraphQL: At least 1 approving review is required by reviewers with write access."), errExit
+	})
+	execFake := execGH
+	execGH = func(ctx context.Context, args ...string) ([]byte, error) {
+		out, err := execFake(ctx, args...)
+		during = ctx.Err()
+		return out, err
+	}
+	err := MergePR(ctx, "o/r", 1, "--squash", false)
+	if during != nil {
+		t.Fatalf("the merge's context ended with its caller's: %v", during)
+	}
+	if !errors.Is(err, ErrNotMergeable) {
+		t.Fatalf("MergePR = %v, want gh's own failure rather than a cancellation", err)
+	}
+}
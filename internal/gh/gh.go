@@ -3,64 +3,125 @@ package gh
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"git-shippr/internal/audit"
+	"git-shippr/internal/dryrun"
+)
+
+type Actor struct {
+	Login string `json:"login"`
+	IsBot bool   `json:"is_bot,omitempty"`
+}
+
+// ReviewRequest is a pending review request for a user (Login) or a team
+// (Slug, Name).
+type ReviewRequest struct {
+	TypeName string `json:"__typename"`
+	Login    string `json:"login,omitempty"`
+	Slug     string `json:"slug,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+type Review struct {
+	Author      Actor  `json:"author"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submittedAt"`
+}
+
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Check is one entry of a statusCheckRollup: either a CheckRun (Name,
+// Status, Conclusion) or a legacy StatusContext (Context, State).
+type Check struct {
+	TypeName   string `json:"__typename"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Conclusion string `json:"conclusion,omitempty"`
+	DetailsUrl string `json:"detailsUrl,omitempty"`
+	State      string `json:"state,omitempty"`
+	TargetUrl  string `json:"targetUrl,omitempty"`
+	Context    string `json:"context,omitempty"`
+	CreatedAt  string `json:"createdAt,omitempty"`
+}
+
+const (
+	ChecksPassing = "SUCCESS"
+	ChecksFailing = "FAILURE"
+	ChecksPending = "PENDING"
 )
 
 type PR struct {
-	Number      int    `json:"number"`
-	Title       string `json:"title"`
-	HeadRefName string `json:"headRefName"`
-	Author      struct {
-		Login string `json:"login"`
-	} `json:"author"`
-	State     string `json:"state"`
-	CreatedAt string `json:"createdAt"`
-	UpdatedAt string `json:"updatedAt"`
-	Mergeable string `json:"mergeable"`
-	Labels    []struct {
-		Name string `json:"name"`
-	} `json:"labels"`
+	Number            int             `json:"number"`
+	Title             string          `json:"title"`
+	HeadRefName       string          `json:"headRefName"`
+	BaseRefName       string          `json:"baseRefName"`
+	Author            Actor           `json:"author"`
+	State             string          `json:"state"`
+	CreatedAt         string          `json:"createdAt"`
+	UpdatedAt         string          `json:"updatedAt"`
+	Mergeable         string          `json:"mergeable"`
+	MergeStateStatus  string          `json:"mergeStateStatus"`
+	Labels            []Label         `json:"labels"`
+	IsDraft           bool            `json:"isDraft"`
+	ReviewDecision    string          `json:"reviewDecision"`
+	ReviewRequests    []ReviewRequest `json:"reviewRequests"`
+	StatusCheckRollup []Check         `json:"statusCheckRollup"`
+	MergedAt          string          `json:"mergedAt,omitempty"`
+	ClosedAt          string          `json:"closedAt,omitempty"`
+}
+
+const prListFields = "number,title,headRefName,baseRefName,author,state,createdAt,updatedAt,mergeable,mergeStateStatus,labels,isDraft,reviewDecision,reviewRequests,statusCheckRollup,mergedAt,closedAt"
+
+// Commit identifies a commit by object ID.
+type Commit struct {
+	Oid string `json:"oid"`
 }
 
 type PRDetails struct {
-	Number      int    `json:"number"`
-	Title       string `json:"title"`
-	Body        string `json:"body"`
-	HeadRefName string `json:"headRefName"`
-	BaseRefName string `json:"baseRefName"`
-	Author      struct {
-		Login string `json:"login"`
-	} `json:"author"`
-	State          string `json:"state"`
-	Mergeable      string `json:"mergeable"`
-	CreatedAt      string `json:"createdAt"`
-	UpdatedAt      string `json:"updatedAt"`
-	Additions      int    `json:"additions"`
-	Deletions      int    `json:"deletions"`
-	ChangedFiles   int    `json:"changedFiles"`
-	ReviewRequests []struct {
-		RequestedReviewer struct {
-			Login string `json:"login"`
-		} `json:"requestedReviewer"`
-	} `json:"reviewRequests"`
-	Reviews []struct {
-		Author struct {
-			Login string `json:"login"`
-		} `json:"author"`
-		State       string `json:"state"`
-		SubmittedAt string `json:"submittedAt"`
-	} `json:"reviews"`
-	StatusCheckRollup []struct {
-		State     string `json:"state"`
-		TargetUrl string `json:"targetUrl,omitempty"`
-		Context   string `json:"context"`
-		CreatedAt string `json:"createdAt"`
-	} `json:"statusCheckRollup"`
-	Files []struct {
+	Number            int             `json:"number"`
+	Title             string          `json:"title"`
+	Body              string          `json:"body"`
+	URL               string          `json:"url"`
+	HeadRefName       string          `json:"headRefName"`
+	BaseRefName       string          `json:"baseRefName"`
+	Author            Actor           `json:"author"`
+	State             string          `json:"state"`
+	IsDraft           bool            `json:"isDraft"`
+	Mergeable         string          `json:"mergeable"`
+	MergeStateStatus  string          `json:"mergeStateStatus"`
+	ReviewDecision    string          `json:"reviewDecision"`
+	CreatedAt         string          `json:"createdAt"`
+	UpdatedAt         string          `json:"updatedAt"`
+	Additions         int             `json:"additions"`
+	Deletions         int             `json:"deletions"`
+	ChangedFiles      int             `json:"changedFiles"`
+	ReviewRequests    []ReviewRequest `json:"reviewRequests"`
+	Reviews           []Review        `json:"reviews"`
+	StatusCheckRollup []Check         `json:"statusCheckRollup"`
+	// MergeCommit is the merge, squash or rebase commit on the base branch;
+	// nil until the PR is merged.
+	MergeCommit *Commit `json:"mergeCommit"`
+	// HeadRepository and HeadRepositoryOwner name the repository
+	// HeadRefName lives in: a fork for cross-repository PRs. The name is
+	// empty when that repository was deleted.
+	HeadRepository struct {
+		Name string `json:"name"`
+	} `json:"headRepository"`
+	HeadRepositoryOwner Actor `json:"headRepositoryOwner"`
+	Files               []struct {
 		Path      string `json:"path"`
 		Additions int    `json:"additions"`
 		Deletions int    `json:"deletions"`
@@ -68,59 +129,278 @@ type PRDetails struct {
 	} `json:"files"`
 }
 
+// HeadRepo returns the owner/name of the repository the PR's branch lives
+// in, or "" if it no longer exists.
+func (d *PRDetails) HeadRepo() string {
+	if d.HeadRepository.Name == "" || d.HeadRepositoryOwner.Login == "" {
+		return ""
+	}
+	return Slug(d.HeadRepositoryOwner.Login, d.HeadRepository.Name)
+}
+
 func Slug(org, repo string) string { return fmt.Sprintf("%s/%s", org, repo) }
 
-func ListPRs(ctx context.Context, repo string) ([]PR, error) {
-	cmd := exec.CommandContext(ctx, "gh", "pr", "list", "--repo", repo, "--json", "number,title,headRefName,author,state,createdAt,updatedAt,mergeable,labels")
-	out, err := cmd.CombinedOutput()
+// PR states accepted by ListOptions.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateMerged = "merged"
+	StateAll    = "all"
+)
+
+// States lists the selectable PR states in display order.
+var States = []string{StateOpen, StateMerged, StateClosed, StateAll}
+
+// DefaultLimit is how many results gh returns when no limit is given.
+const DefaultLimit = 30
+
+// LimitAll asks for every result. gh pages through them 100 at a time.
+const LimitAll = -1
+
+// maxLimit stands in for "no limit" on gh's --limit flag.
+const maxLimit = 1_000_000
+
+// ListOptions narrows ListPRs. The zero value lists DefaultLimit open PRs.
+type ListOptions struct {
+	State string
+	// Limit caps the number of PRs: 0 means DefaultLimit, LimitAll means
+	// no cap.
+	Limit int
+	// Fresh skips the response cache in org scans, for callers that act on
+	// what they list. ListPRs always fetches.
+	Fresh bool
+}
+
+// Truncated reports whether n results for these options may have left
+// more behind.
+func (o ListOptions) Truncated(n int) bool {
+	return truncated(o.Limit, n)
+}
+
+func truncated(limit, n int) bool {
+	if limit < 0 {
+		return false
+	}
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	return n >= limit
+}
+
+func limitArgs(limit int) []string {
+	switch {
+	case limit < 0:
+		return []string{"--limit", fmt.Sprint(maxLimit)}
+	case limit > 0:
+		return []string{"--limit", fmt.Sprint(limit)}
+	}
+	return nil
+}
+
+func (o ListOptions) args() []string {
+	var args []string
+	if o.State != "" && o.State != StateOpen {
+		// History is most useful newest-activity first, e.g. "the PR
+		// that merged yesterday".
+		args = append(args, "--state", o.State, "--search", "sort:updated-desc")
+	}
+	return append(args, limitArgs(o.Limit)...)
+}
+
+func ListPRs(ctx context.Context, repo string, opts ListOptions) ([]PR, error) {
+	args := append([]string{"pr", "list", "--repo", repo, "--json", prListFields}, opts.args()...)
+	out, err := runGH(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("gh pr list failed: %w\n%s", err, string(out))
+		return nil, newError(ctx, "gh pr list", err, out)
 	}
 	var prs []PR
 	if err := json.Unmarshal(out, &prs); err != nil {
 		return nil, fmt.Errorf("parse gh json: %w", err)
 	}
+	cachePut(repo, listKey(opts), out)
 	return prs, nil
 }
 
+// listPRsCached serves ListPRs from the cache while it is fresh, for callers
+// like the org listing that don't revalidate in the background.
+func listPRsCached(ctx context.Context, repo string, opts ListOptions) ([]PR, error) {
+	if prs, _, ok := CachedPRs(repo, opts); ok {
+		return prs, nil
+	}
+	return ListPRs(ctx, repo, opts)
+}
+
 func GetPRDetails(ctx context.Context, repo string, number int) (*PRDetails, error) {
-	fields := "number,title,body,headRefName,baseRefName,author,state,mergeable,createdAt,updatedAt,additions,deletions,changedFiles,reviewRequests,reviews,statusCheckRollup,files"
-	cmd := exec.CommandContext(ctx, "gh", "pr", "view", fmt.Sprint(number), "--repo", repo, "--json", fields)
-	out, err := cmd.CombinedOutput()
+	fields := "number,title,body,headRefName,baseRefName,author,state,isDraft,mergeable,mergeStateStatus,reviewDecision,createdAt,updatedAt,additions,deletions,changedFiles,reviewRequests,reviews,statusCheckRollup,files,url,mergeCommit,headRepository,headRepositoryOwner"
+	out, err := runGH(ctx, "pr", "view", fmt.Sprint(number), "--repo", repo, "--json", fields)
 	if err != nil {
-		return nil, fmt.Errorf("gh pr view failed: %w\n%s", err, string(out))
+		return nil, newError(ctx, "gh pr view", err, out)
 	}
 	var details PRDetails
 	if err := json.Unmarshal(out, &details); err != nil {
 		return nil, fmt.Errorf("parse gh json: %w", err)
 	}
+	cachePut(repo, detailsKey(number), out)
 	return &details, nil
 }
 
+// CurrentUser returns the login of the authenticated gh user.
+func CurrentUser(ctx context.Context) (string, error) {
+	out, err := runGH(ctx, "api", "user", "--jq", ".login")
+	if err != nil {
+		return "", newError(ctx, "gh api user", err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func ViewPRWeb(ctx context.Context, repo string, number int) error {
 	cmd := exec.CommandContext(ctx, "gh", "pr", "view", fmt.Sprint(number), "--repo", repo, "--web")
 	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("gh pr view failed: %w\n%s", err, string(out))
+		return newError(ctx, "gh pr view", err, out)
 	}
 	return nil
 }
 
+// mutateTimeout bounds a single change, which isn't cancelled with its
+// context (see mutate).
+const mutateTimeout = 2 * time.Minute
+
+// mutate runs a gh command that changes something on GitHub and records it
+// in the audit log as e. In dry-run mode the invocation is recorded by the
+// dry-run recorder instead and reported as successful.
+//
+// A change is only started if ctx is still live, and once started it runs to
+// completion even if ctx is cancelled: killing gh halfway through, e.g. a
+// merge, would leave it unknown whether GitHub applied it.
+func mutate(ctx context.Context, e audit.Entry, args ...string) ([]byte, error) {
+	if dryrun.Intercept("gh", args...) {
+		return nil, nil
+	}
+	dctx, cancel := detached(ctx, mutateTimeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		record(dctx, e, nil, err)
+		return nil, err
+	}
+	out, err := execGH(dctx, args...)
+	invalidateCache(e.Repo)
+	record(dctx, e, out, err)
+	if err != nil {
+		return out, completedError{err}
+	}
+	return out, nil
+}
+
+// completedError marks the failure of a change that ran to completion (see
+// mutate), so newError reports gh's reason even if ctx was cancelled since.
+type completedError struct{ error }
+
+func (e completedError) Unwrap() error { return e.error }
+
+var (
+	actorOnce sync.Once
+	actor     string
+)
+
+// record appends e to the audit log with its outcome, filling in who ran it
+// and the PR's head commit. Lookup failures only leave those fields empty;
+// a failing audit write is reported on stderr but never fails the action.
+func record(ctx context.Context, e audit.Entry, out []byte, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	actorOnce.Do(func() { actor, _ = CurrentUser(ctx) })
+	e.Actor = actor
+	if e.PR > 0 && e.HeadSHA == "" {
+		if h, herr := GetPRHead(ctx, e.Repo, e.PR); herr == nil {
+			e.HeadSHA = h.SHA
+		}
+	}
+	e.Outcome = audit.Success
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.Outcome = audit.Cancelled
+	case err != nil:
+		e.Outcome = audit.Failure
+		e.Error = strings.TrimSpace(fmt.Sprintf("%v: %s", err, out))
+	}
+	if aerr := audit.Append(e); aerr != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", aerr)
+	}
+}
+
+// PRHead is a PR's head branch and the commit it points at. For merged PRs
+// GitHub keeps the commit the branch pointed at when it was merged.
+type PRHead struct {
+	Branch          string `json:"headRefName"`
+	SHA             string `json:"headRefOid"`
+	CrossRepository bool   `json:"isCrossRepository"`
+}
+
+func GetPRHead(ctx context.Context, repo string, number int) (*PRHead, error) {
+	out, err := runGH(ctx, "pr", "view", fmt.Sprint(number), "--repo", repo, "--json", "headRefName,headRefOid,isCrossRepository")
+	if err != nil {
+		return nil, newError(ctx, "gh pr view", err, out)
+	}
+	var h PRHead
+	if err := json.Unmarshal(out, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse PR head: %w", err)
+	}
+	return &h, nil
+}
+
 func MergePR(ctx context.Context, repo string, number int, strategy string, deleteBranch bool) error {
 	args := []string{"pr", "merge", fmt.Sprint(number), "--repo", repo, strategy}
 	if deleteBranch {
 		args = append(args, "--delete-branch")
 	}
-	cmd := exec.CommandContext(ctx, "gh", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("gh pr merge failed: %w\n%s", err, string(out))
+	e := audit.Entry{Action: audit.Merge, Repo: repo, PR: number, Strategy: strategy, DeleteBranch: deleteBranch}
+	if audit.Enabled() {
+		// Capture the head before merging so a deleted branch can be
+		// restored with `shippr restore-branch`.
+		if h, err := GetPRHead(ctx, repo, number); err == nil {
+			e.HeadSHA = h.SHA
+			if deleteBranch {
+				e.Branch = h.Branch
+			}
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		out, err := mutate(ctx, e, args...)
+		if err == nil {
+			return nil
+		}
+		// Merges aren't blindly retried: a connection that dropped after
+		// GitHub accepted the merge would otherwise fail or merge twice.
+		// Only retry once the PR is confirmed to still be open.
+		if kindOf(ctx, out) == KindNetwork && attempt < maxTransientRetries {
+			switch state, verr := prState(ctx, repo, number); {
+			case verr != nil:
+			case state == StateMerged:
+				return nil
+			case state == StateOpen:
+				if sleep(ctx, backoff(attempt)) == nil {
+					continue
+				}
+			}
+		}
+		return newError(ctx, "gh pr merge", err, out)
 	}
-	return nil
+}
+
+// prState returns a PR's state in lowercase (open, merged or closed).
+func prState(ctx context.Context, repo string, number int) (string, error) {
+	out, err := runGH(ctx, "pr", "view", fmt.Sprint(number), "--repo", repo, "--json", "state", "--jq", ".state")
+	if err != nil {
+		return "", newError(ctx, "gh pr view", err, out)
+	}
+	return strings.ToLower(strings.TrimSpace(string(out))), nil
 }
 
 func ApprovePR(ctx context.Context, repo string, number int) error {
-	cmd := exec.CommandContext(ctx, "gh", "pr", "review", fmt.Sprint(number), "--repo", repo, "--approve")
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("gh pr approve failed: %w\n%s", err, string(out))
+	e := audit.Entry{Action: audit.Approve, Repo: repo, PR: number}
+	if out, err := mutate(ctx, e, "pr", "review", fmt.Sprint(number), "--repo", repo, "--approve"); err != nil {
+		return newError(ctx, "gh pr approve", err, out)
 	}
 	return nil
 }
@@ -130,13 +410,239 @@ func RequestChanges(ctx context.Context, repo string, number int, comment string
 	if comment != "" {
 		args = append(args, "--body", comment)
 	}
-	cmd := exec.CommandContext(ctx, "gh", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("gh pr request changes failed: %w\n%s", err, string(out))
+	e := audit.Entry{Action: audit.RequestChanges, Repo: repo, PR: number, Detail: comment}
+	if out, err := mutate(ctx, e, args...); err != nil {
+		return newError(ctx, "gh pr request changes", err, out)
 	}
 	return nil
 }
 
+// RequestReview (re-)requests reviews from the given users. Reviewers who
+// already reviewed are asked again.
+func RequestReview(ctx context.Context, repo string, number int, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return fmt.Errorf("no reviewers to request")
+	}
+	args := []string{"api", "-X", "POST", fmt.Sprintf("repos/%s/pulls/%d/requested_reviewers", repo, number)}
+	for _, r := range reviewers {
+		args = append(args, "-f", "reviewers[]="+r)
+	}
+	e := audit.Entry{Action: audit.RequestReview, Repo: repo, PR: number, Detail: strings.Join(reviewers, ",")}
+	if out, err := mutate(ctx, e, args...); err != nil {
+		return newError(ctx, "gh request review", err, out)
+	}
+	return nil
+}
+
+// ReviewersToRerequest picks who to ask again: previous reviewers (other
+// than the author) whose latest review is not an approval, or every previous
+// reviewer if all of them approved.
+func ReviewersToRerequest(pr *PRDetails) []string {
+	latest := map[string]string{}
+	var order []string
+	for _, r := range pr.Reviews {
+		login := r.Author.Login
+		if login == "" || login == pr.Author.Login {
+			continue
+		}
+		if _, seen := latest[login]; !seen {
+			order = append(order, login)
+		}
+		latest[login] = r.State
+	}
+	var pending []string
+	for _, login := range order {
+		if latest[login] != "APPROVED" {
+			pending = append(pending, login)
+		}
+	}
+	if len(pending) == 0 {
+		return order
+	}
+	return pending
+}
+
+// UpdateBranch brings the PR branch up to date with its base on GitHub,
+// either by merging the base in or, with rebase, by rebasing onto it.
+func UpdateBranch(ctx context.Context, repo string, number int, rebase bool) error {
+	args := []string{"pr", "update-branch", fmt.Sprint(number), "--repo", repo}
+	if rebase {
+		args = append(args, "--rebase")
+	}
+	e := audit.Entry{Action: audit.UpdateBranch, Repo: repo, PR: number}
+	if rebase {
+		e.Strategy = "--rebase"
+	}
+	if out, err := mutate(ctx, e, args...); err != nil {
+		return newError(ctx, "gh pr update-branch", err, out)
+	}
+	return nil
+}
+
+// EditBase retargets a PR to a different base branch.
+func EditBase(ctx context.Context, repo string, number int, base string) error {
+	e := audit.Entry{Action: audit.EditBase, Repo: repo, PR: number, Branch: base}
+	if out, err := mutate(ctx, e, "pr", "edit", fmt.Sprint(number), "--repo", repo, "--base", base); err != nil {
+		return newError(ctx, "gh pr edit", err, out)
+	}
+	return nil
+}
+
+// DeleteBranch deletes a branch ref on GitHub.
+func DeleteBranch(ctx context.Context, repo, branch string) error {
+	e := audit.Entry{Action: audit.DeleteBranch, Repo: repo, Branch: branch}
+	if audit.Enabled() {
+		e.HeadSHA, _ = RefSHA(ctx, repo, branch)
+	}
+	if out, err := mutate(ctx, e, "api", "-X", "DELETE", fmt.Sprintf("repos/%s/git/refs/heads/%s", repo, branch)); err != nil {
+		return newError(ctx, "gh delete branch", err, out)
+	}
+	return nil
+}
+
+// RefSHA returns the commit a branch points at.
+func RefSHA(ctx context.Context, repo, branch string) (string, error) {
+	out, err := runGH(ctx, "api", fmt.Sprintf("repos/%s/git/ref/heads/%s", repo, branch), "--jq", ".object.sha")
+	if err != nil {
+		return "", newError(ctx, "gh api git/ref", err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CreateBranch creates branch at sha, e.g. to restore a deleted PR branch.
+// It fails if the branch already exists.
+func CreateBranch(ctx context.Context, repo, branch, sha string) error {
+	e := audit.Entry{Action: audit.RestoreBranch, Repo: repo, Branch: branch, HeadSHA: sha}
+	if out, err := mutate(ctx, e, "api", "-X", "POST", fmt.Sprintf("repos/%s/git/refs", repo),
+		"-f", "ref=refs/heads/"+branch, "-f", "sha="+sha); err != nil {
+		return newError(ctx, "gh create branch", err, out)
+	}
+	return nil
+}
+
+// CreatePR opens a PR from head into base and returns its number and URL.
+// In dry-run mode nothing is created and the number is 0.
+func CreatePR(ctx context.Context, repo, base, head, title, body string) (int, string, error) {
+	e := audit.Entry{Action: audit.CreatePR, Repo: repo, Branch: head, Detail: title}
+	out, err := mutate(ctx, e, "pr", "create", "--repo", repo, "--base", base, "--head", head, "--title", title, "--body", body)
+	if err != nil {
+		return 0, "", newError(ctx, "gh pr create", err, out)
+	}
+	url := strings.TrimSpace(string(out))
+	if i := strings.LastIndex(url, "\n"); i >= 0 {
+		url = url[i+1:]
+	}
+	number, _ := strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+	return number, url, nil
+}
+
+// ClosePR closes a PR without merging it.
+func ClosePR(ctx context.Context, repo string, number int) error {
+	e := audit.Entry{Action: audit.Close, Repo: repo, PR: number}
+	if out, err := mutate(ctx, e, "pr", "close", fmt.Sprint(number), "--repo", repo); err != nil {
+		return newError(ctx, "gh pr close", err, out)
+	}
+	return nil
+}
+
+// ReopenPR reopens a closed, unmerged PR.
+func ReopenPR(ctx context.Context, repo string, number int) error {
+	e := audit.Entry{Action: audit.Reopen, Repo: repo, PR: number}
+	if out, err := mutate(ctx, e, "pr", "reopen", fmt.Sprint(number), "--repo", repo); err != nil {
+		return newError(ctx, "gh pr reopen", err, out)
+	}
+	return nil
+}
+
+// AddLabels adds labels to a PR, creating nothing: the labels must exist.
+func AddLabels(ctx context.Context, repo string, number int, labels ...string) error {
+	e := audit.Entry{Action: audit.Label, Repo: repo, PR: number, Detail: strings.Join(labels, ",")}
+	if out, err := mutate(ctx, e, "pr", "edit", fmt.Sprint(number), "--repo", repo, "--add-label", strings.Join(labels, ",")); err != nil {
+		return newError(ctx, "gh pr edit --add-label", err, out)
+	}
+	return nil
+}
+
+// RemoveLabels takes labels off a PR.
+func RemoveLabels(ctx context.Context, repo string, number int, labels ...string) error {
+	e := audit.Entry{Action: audit.Unlabel, Repo: repo, PR: number, Detail: strings.Join(labels, ",")}
+	if out, err := mutate(ctx, e, "pr", "edit", fmt.Sprint(number), "--repo", repo, "--remove-label", strings.Join(labels, ",")); err != nil {
+		return newError(ctx, "gh pr edit --remove-label", err, out)
+	}
+	return nil
+}
+
+// LabeledAt returns when label was last added to a PR, from its timeline.
+// ok is false when the timeline has no such event.
+func LabeledAt(ctx context.Context, repo string, number int, label string) (t time.Time, ok bool, err error) {
+	// --paginate runs the filter per page, printing one line each.
+	jq := fmt.Sprintf(`[.[] | select(.event == "labeled" and (.label.name | ascii_downcase) == %q)] | last | .created_at // ""`,
+		strings.ToLower(label))
+	out, err := runGH(ctx, "api", "--paginate", fmt.Sprintf("repos/%s/issues/%d/events", repo, number), "--jq", jq)
+	if err != nil {
+		return time.Time{}, false, newError(ctx, "gh api issue events", err, out)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if at, perr := time.Parse(time.RFC3339, strings.TrimSpace(line)); perr == nil {
+			t, ok = at, true
+		}
+	}
+	return t, ok, nil
+}
+
+// Comment posts body as a comment on a PR.
+func Comment(ctx context.Context, repo string, number int, body string) error {
+	e := audit.Entry{Action: audit.Comment, Repo: repo, PR: number, Detail: body}
+	if out, err := mutate(ctx, e, "pr", "comment", fmt.Sprint(number), "--repo", repo, "--body", body); err != nil {
+		return newError(ctx, "gh pr comment", err, out)
+	}
+	return nil
+}
+
+// CheckState folds a statusCheckRollup into a single state: ChecksFailing if
+// any check failed, ChecksPending if any is still running, ChecksPassing if
+// all succeeded, or "" when there are no checks.
+func CheckState(checks []Check) string {
+	if len(checks) == 0 {
+		return ""
+	}
+	pending := false
+	for _, c := range checks {
+		switch c.result() {
+		case ChecksFailing:
+			return ChecksFailing
+		case ChecksPending:
+			pending = true
+		}
+	}
+	if pending {
+		return ChecksPending
+	}
+	return ChecksPassing
+}
+
+func (c Check) result() string {
+	if c.TypeName == "StatusContext" || (c.Status == "" && c.State != "") {
+		switch c.State {
+		case "SUCCESS":
+			return ChecksPassing
+		case "PENDING", "EXPECTED":
+			return ChecksPending
+		default:
+			return ChecksFailing
+		}
+	}
+	if c.Status != "COMPLETED" {
+		return ChecksPending
+	}
+	switch c.Conclusion {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		return ChecksPassing
+	default:
+		return ChecksFailing
+	}
+}
+
 func EnsureGH(ctx context.Context) error {
 	cmd := exec.CommandContext(ctx, "gh", "--version")
 	if err := cmd.Run(); err != nil {
@@ -170,59 +676,132 @@ type Repository struct {
 	Owner repoOwner `json:"owner"`
 }
 
+// ListOrgRepos lists up to limit repositories of org, with the same limit
+// semantics as ListOptions (0 = DefaultLimit, LimitAll = every repository).
 func ListOrgRepos(ctx context.Context, org string, limit int) ([]Repository, error) {
-	args := []string{"repo", "list", org, "--json", "name,owner"}
-	if limit > 0 {
-		args = append(args, "--limit", fmt.Sprint(limit))
+	key := fmt.Sprintf("repo list limit=%d", limit)
+	var repos []Repository
+	if _, ok := cacheGet(org, key, &repos); ok {
+		return repos, nil
 	}
-	cmd := exec.CommandContext(ctx, "gh", args...)
-	out, err := cmd.CombinedOutput()
+	args := append([]string{"repo", "list", org, "--json", "name,owner"}, limitArgs(limit)...)
+	out, err := runGH(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("gh repo list failed: %w\n%s", err, string(out))
+		return nil, newError(ctx, "gh repo list", err, out)
 	}
-	var repos []Repository
 	if err := json.Unmarshal(out, &repos); err != nil {
 		return nil, fmt.Errorf("parse gh json: %w", err)
 	}
+	cachePut(org, key, out)
 	return repos, nil
 }
 
 type RepoPR struct {
 	Repo string
 	PR   PR
+	// Reasons records why a PR showed up in a cross-repo search (see Inbox).
+	Reasons []string
+}
+
+// OrgPRs is the result of ListOrgPRs, with enough detail to tell the user
+// when limits cut the listing short.
+type OrgPRs struct {
+	PRs []RepoPR
+	// ReposTruncated is set when the org may have more repositories than
+	// were listed.
+	ReposTruncated bool
+	// Truncated lists repositories that may have more matching PRs.
+	Truncated []string
+	// Failed lists repositories whose PRs couldn't be fetched, e.g. because
+	// GitHub kept throttling them.
+	Failed []string
 }
 
-func ListOpenPRsForOrg(ctx context.Context, org string, limitRepos int) ([]RepoPR, error) {
+// ListOrgPRs lists PRs matching opts in up to limitRepos repositories of
+// org (see ListOrgRepos for the limit semantics). Repositories are fetched
+// concurrently; concurrency backs off while GitHub rate-limits the scan and
+// starts at one when the remaining quota can't cover every repository.
+func ListOrgPRs(ctx context.Context, org string, limitRepos int, opts ListOptions) (*OrgPRs, error) {
+	return StreamOrgPRs(ctx, org, limitRepos, opts, nil)
+}
+
+// OrgBatch reports progress of a streamed org scan: one repository's result,
+// or, for the first batch, just the number of repositories to scan.
+type OrgBatch struct {
+	// Repo is empty for the first batch.
+	Repo string
+	PRs  []RepoPR
+	// Err is why Repo couldn't be listed.
+	Err error
+	// Truncated reports that opts.Limit cut Repo's listing short.
+	Truncated bool
+	// Scanned of Total repositories are done, including this one.
+	Scanned, Total int
+}
+
+// StreamOrgPRs is ListOrgPRs calling onBatch as each repository finishes, so
+// callers can show results before the slowest repository is done. onBatch
+// runs on the calling goroutine, one batch at a time; it may be nil.
+func StreamOrgPRs(ctx context.Context, org string, limitRepos int, opts ListOptions, onBatch func(OrgBatch)) (*OrgPRs, error) {
 	repos, err := ListOrgRepos(ctx, org, limitRepos)
 	if err != nil {
 		return nil, err
 	}
-	concurrency := max(runtime.NumCPU(), 4)
-	sem := make(chan struct{}, concurrency)
+	if onBatch == nil {
+		onBatch = func(OrgBatch) {}
+	}
+	onBatch(OrgBatch{Total: len(repos)})
+	res := &OrgPRs{ReposTruncated: truncated(limitRepos, len(repos))}
+	ceil := max(runtime.NumCPU(), 4)
+	start := ceil
+	if rl, err := GetRateLimit(ctx); err == nil && rl.GraphQL.Remaining < len(repos) {
+		start = 1
+	}
+	lim := newLimiter(start, ceil)
+	ctx = withLimiter(ctx, lim)
 	var wg sync.WaitGroup
-	resCh := make(chan []RepoPR, len(repos))
+	resCh := make(chan OrgBatch, len(repos))
 	for _, r := range repos {
 		repoSlug := Slug(r.Owner.Login, r.Name)
 		wg.Add(1)
 		go func(slug string) {
 			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-			prs, e := ListPRs(ctx, slug)
-			if e != nil {
+			if err := lim.acquire(ctx); err != nil {
+				resCh <- OrgBatch{Repo: slug, Err: err}
+				return
+			}
+			defer lim.release()
+			list := listPRsCached
+			if opts.Fresh {
+				list = ListPRs
+			}
+			prs, err := list(ctx, slug, opts)
+			if err != nil {
+				resCh <- OrgBatch{Repo: slug, Err: err}
 				return
 			}
-			acc := make([]RepoPR, 0, len(prs))
+			b := OrgBatch{Repo: slug, Truncated: opts.Truncated(len(prs)), PRs: make([]RepoPR, 0, len(prs))}
 			for _, p := range prs {
-				acc = append(acc, RepoPR{Repo: slug, PR: p})
+				b.PRs = append(b.PRs, RepoPR{Repo: slug, PR: p})
 			}
-			resCh <- acc
+			resCh <- b
 		}(repoSlug)
 	}
 	go func() { wg.Wait(); close(resCh) }()
-	var all []RepoPR
-	for batch := range resCh {
-		all = append(all, batch...)
+	scanned := 0
+	for b := range resCh {
+		scanned++
+		res.PRs = append(res.PRs, b.PRs...)
+		switch {
+		case b.Err != nil:
+			res.Failed = append(res.Failed, b.Repo)
+		case b.Truncated:
+			res.Truncated = append(res.Truncated, b.Repo)
+		}
+		b.Scanned, b.Total = scanned, len(repos)
+		onBatch(b)
 	}
-	return all, nil
+	sort.Strings(res.Truncated)
+	sort.Strings(res.Failed)
+	return res, nil
 }
@@ -1,6 +1,12 @@
 package gh
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"git-shippr/internal/dryrun"
+)
 
 func TestSlug(t *testing.T) {
 	if Slug("org", "repo") != "org/repo" {
@@ -22,3 +28,103 @@ func TestHumanStrategy(t *testing.T) {
 		t.Fatalf("empty should squash")
 	}
 }
+
+func TestCheckState(t *testing.T) {
+	if CheckState(nil) != "" {
+		t.Fatalf("no checks should be empty state")
+	}
+	passing := []Check{
+		{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SUCCESS"},
+		{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SKIPPED"},
+		{TypeName: "StatusContext", State: "SUCCESS"},
+	}
+	if got := CheckState(passing); got != ChecksPassing {
+		t.Fatalf("expected passing, got %q", got)
+	}
+	pending := append(passing, Check{TypeName: "CheckRun", Status: "IN_PROGRESS"})
+	if got := CheckState(pending); got != ChecksPending {
+		t.Fatalf("expected pending, got %q", got)
+	}
+	failing := append(pending, Check{TypeName: "StatusContext", State: "ERROR"})
+	if got := CheckState(failing); got != ChecksFailing {
+		t.Fatalf("expected failing, got %q", got)
+	}
+}
+
+func TestReviewersToRerequest(t *testing.T) {
+	pr := &PRDetails{Author: Actor{Login: "me"}}
+	pr.Reviews = []Review{
+		{Author: Actor{Login: "alice"}, State: "CHANGES_REQUESTED"},
+		{Author: Actor{Login: "bob"}, State: "APPROVED"},
+		{Author: Actor{Login: "me"}, State: "COMMENTED"},
+		{Author: Actor{Login: "alice"}, State: "COMMENTED"},
+	}
+	got := ReviewersToRerequest(pr)
+	if len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("expected only alice, got %v", got)
+	}
+	pr.Reviews = pr.Reviews[1:2]
+	got = ReviewersToRerequest(pr)
+	if len(got) != 1 || got[0] != "bob" {
+		t.Fatalf("all approved should re-request everyone, got %v", got)
+	}
+}
+
+func TestMutationsAreRecordedInDryRun(t *testing.T) {
+	dryrun.Enable(nil)
+	defer dryrun.Disable()
+	if err := MergePR(context.Background(), "acme/web", 12, "--squash", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequestChanges(context.Background(), "acme/web", 12, "needs tests"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"gh pr merge 12 --repo acme/web --squash --delete-branch",
+		"gh pr review 12 --repo acme/web --request-changes --body 'needs tests'",
+	}
+	got := dryrun.Calls()
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestListOptionsArgs(t *testing.T) {
+	cases := []struct {
+		opts ListOptions
+		want string
+	}{
+		{ListOptions{}, ""},
+		{ListOptions{State: StateOpen, Limit: 50}, "--limit 50"},
+		{ListOptions{State: StateMerged, Limit: 60}, "--state merged --search sort:updated-desc --limit 60"},
+		{ListOptions{Limit: LimitAll}, "--limit 1000000"},
+	}
+	for _, c := range cases {
+		if got := strings.Join(c.opts.args(), " "); got != c.want {
+			t.Errorf("%+v: got %q, want %q", c.opts, got, c.want)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	cases := []struct {
+		limit, n int
+		want     bool
+	}{
+		{0, 29, false},
+		{0, 30, true},
+		{50, 50, true},
+		{50, 12, false},
+		{LimitAll, 5000, false},
+	}
+	for _, c := range cases {
+		if got := (ListOptions{Limit: c.limit}).Truncated(c.n); got != c.want {
+			t.Errorf("limit %d, n %d: got %v", c.limit, c.n, got)
+		}
+	}
+}
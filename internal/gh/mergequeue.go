@@ -0,0 +1,126 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-shippr/internal/audit"
+)
+
+// MergeQueueStatus describes GitHub's merge queue for a PR's base branch and
+// the PR's place in it. Required is false when the branch has no queue.
+type MergeQueueStatus struct {
+	Required      bool
+	URL           string
+	PullRequestID string
+	InQueue       bool
+	// Position is 1-based; 0 when the PR is not queued.
+	Position   int
+	State      string
+	EnqueuedAt string
+	ETA        time.Duration
+}
+
+const mergeQueueQuery = `query($owner: String!, $name: String!, $number: Int!, $branch: String!) {
+  repository(owner: $owner, name: $name) {
+    mergeQueue(branch: $branch) { url }
+    pullRequest(number: $number) {
+      id
+      isInMergeQueue
+      mergeQueueEntry { position state enqueuedAt estimatedTimeToMerge }
+    }
+  }
+}`
+
+// GetMergeQueue reports whether base requires a merge queue and, if so,
+// where the PR stands in it.
+func GetMergeQueue(ctx context.Context, repo string, number int, base string) (*MergeQueueStatus, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repo %q", repo)
+	}
+	out, err := runGH(ctx, "api", "graphql",
+		"-f", "query="+mergeQueueQuery,
+		"-f", "owner="+owner,
+		"-f", "name="+name,
+		"-F", fmt.Sprintf("number=%d", number),
+		"-f", "branch="+base)
+	if err != nil {
+		return nil, newError(ctx, "gh merge queue query", err, out)
+	}
+	return parseMergeQueue(out)
+}
+
+func parseMergeQueue(data []byte) (*MergeQueueStatus, error) {
+	var resp struct {
+		Data struct {
+			Repository struct {
+				MergeQueue *struct {
+					URL string `json:"url"`
+				} `json:"mergeQueue"`
+				PullRequest struct {
+					ID              string `json:"id"`
+					IsInMergeQueue  bool   `json:"isInMergeQueue"`
+					MergeQueueEntry *struct {
+						Position             int    `json:"position"`
+						State                string `json:"state"`
+						EnqueuedAt           string `json:"enqueuedAt"`
+						EstimatedTimeToMerge *int   `json:"estimatedTimeToMerge"`
+					} `json:"mergeQueueEntry"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse merge queue response: %w", err)
+	}
+	r := resp.Data.Repository
+	s := &MergeQueueStatus{
+		Required:      r.MergeQueue != nil,
+		PullRequestID: r.PullRequest.ID,
+		InQueue:       r.PullRequest.IsInMergeQueue,
+	}
+	if r.MergeQueue != nil {
+		s.URL = r.MergeQueue.URL
+	}
+	if e := r.PullRequest.MergeQueueEntry; e != nil {
+		s.InQueue = true
+		// GitHub counts from 0 for the entry at the front.
+		s.Position = e.Position + 1
+		s.State = e.State
+		s.EnqueuedAt = e.EnqueuedAt
+		if e.EstimatedTimeToMerge != nil {
+			s.ETA = time.Duration(*e.EstimatedTimeToMerge) * time.Second
+		}
+	}
+	return s, nil
+}
+
+// EnqueuePR adds a PR to its base branch's merge queue. The queue decides the
+// merge method, so no strategy is passed. pullRequestID is the node ID from
+// MergeQueueStatus.
+func EnqueuePR(ctx context.Context, repo string, number int, pullRequestID string) error {
+	return mergeQueueMutation(ctx, audit.Entry{Action: audit.Enqueue, Repo: repo, PR: number},
+		`mutation($id: ID!) { enqueuePullRequest(input: {pullRequestId: $id}) { mergeQueueEntry { position } } }`,
+		pullRequestID)
+}
+
+// DequeuePR removes a PR from its merge queue.
+func DequeuePR(ctx context.Context, repo string, number int, pullRequestID string) error {
+	return mergeQueueMutation(ctx, audit.Entry{Action: audit.Dequeue, Repo: repo, PR: number},
+		`mutation($id: ID!) { dequeuePullRequest(input: {id: $id}) { mergeQueueEntry { id } } }`,
+		pullRequestID)
+}
+
+func mergeQueueMutation(ctx context.Context, e audit.Entry, mutation, id string) error {
+	if id == "" {
+		return fmt.Errorf("%s: missing pull request id", e.Action)
+	}
+	if out, err := mutate(ctx, e, "api", "graphql", "-f", "query="+mutation, "-f", "id="+id); err != nil {
+		return newError(ctx, "gh "+e.Action, err, out)
+	}
+	return nil
+}
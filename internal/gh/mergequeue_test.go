@@ -0,0 +1,28 @@
+package gh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMergeQueue(t *testing.T) {
+	queued := `{"data":{"repository":{"mergeQueue":{"url":"https://github.com/acme/web/queue/main"},
+		"pullRequest":{"id":"PR_1","isInMergeQueue":true,
+		"mergeQueueEntry":{"position":1,"state":"AWAITING_CHECKS","enqueuedAt":"2026-03-01T00:00:00Z","estimatedTimeToMerge":600}}}}}`
+	s, err := parseMergeQueue([]byte(queued))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Required || !s.InQueue || s.Position != 2 || s.State != "AWAITING_CHECKS" || s.ETA != 10*time.Minute || s.PullRequestID != "PR_1" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+
+	none := `{"data":{"repository":{"mergeQueue":null,"pullRequest":{"id":"PR_2","isInMergeQueue":false,"mergeQueueEntry":null}}}}`
+	s, err = parseMergeQueue([]byte(none))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Required || s.InQueue || s.Position != 0 {
+		t.Fatalf("expected no merge queue, got %+v", s)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package gh
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in its own process group so a Ctrl+C in the terminal
+// reaches shippr but not the gh process making a change.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,279 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// execGH runs gh and returns its combined output. Tests replace it with a
+// fake backend.
+var execGH = func(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	if isDetached(ctx) {
+		detach(cmd)
+		// A detached gh can't read the terminal, so it must never prompt.
+		cmd.Env = append(os.Environ(), "GH_PROMPT_DISABLED=1")
+	}
+	return cmd.CombinedOutput()
+}
+
+type detachedKey struct{}
+
+// detached returns a context for a change that must run to completion once
+// started: it ignores parent cancellation, is bounded by timeout instead,
+// and runs gh out of reach of terminal signals.
+func detached(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithValue(context.WithoutCancel(ctx), detachedKey{}, true), timeout)
+}
+
+func isDetached(ctx context.Context) bool {
+	d, _ := ctx.Value(detachedKey{}).(bool)
+	return d
+}
+
+// sleep waits for d or until ctx is done. Tests replace it to avoid waiting.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+const (
+	// maxRateLimitRetries bounds how often a read is retried after GitHub
+	// throttled it.
+	maxRateLimitRetries = 3
+	// maxRateLimitWait is the longest shippr waits out a limit; beyond it
+	// the error is returned rather than leaving the user staring at a
+	// spinner.
+	maxRateLimitWait = 2 * time.Minute
+	// secondaryLimitWait is GitHub's advice for secondary limits: wait at
+	// least a minute.
+	secondaryLimitWait = time.Minute
+)
+
+// rateLimited reports whether gh output describes a 403/429 rate limit
+// response, and whether it was a secondary (abuse) limit.
+func rateLimited(out []byte) (limited, secondary bool) {
+	s := strings.ToLower(string(out))
+	switch {
+	case strings.Contains(s, "secondary rate limit"), strings.Contains(s, "abuse detection"):
+		return true, true
+	case strings.Contains(s, "rate limit"), strings.Contains(s, "http 429"):
+		return true, false
+	}
+	return false, false
+}
+
+// runGH runs a read-only gh command, which makes it safe to retry. Rate
+// limits are waited out: the quota reset for exhausted primary limits, and
+// a minute, doubling, for secondary limits; each throttle is reported to
+// the concurrency limiter in ctx, if any. Network failures are retried with
+// jittered exponential backoff. Callers wrap failures with newError.
+func runGH(ctx context.Context, args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		out, err := execGH(ctx, args...)
+		if err == nil {
+			limiterFrom(ctx).succeeded()
+			return out, nil
+		}
+		kind := kindOf(ctx, out)
+		var wait time.Duration
+		switch {
+		case kind == KindRateLimited && attempt < maxRateLimitRetries:
+			wait = rateLimitWait(ctx, out, attempt)
+			if wait > maxRateLimitWait {
+				return out, err
+			}
+			limiterFrom(ctx).throttled(wait)
+			wait = max(wait, time.Second)
+		case kind == KindNetwork && attempt < maxTransientRetries:
+			wait = backoff(attempt)
+		default:
+			return out, err
+		}
+		if sleep(ctx, wait) != nil {
+			return out, err
+		}
+	}
+}
+
+// rateLimitWait decides how long to wait after a rate-limit response. gh
+// doesn't print response headers, so Retry-After is out of reach; the
+// primary quota's reset comes from the rate_limit endpoint instead.
+func rateLimitWait(ctx context.Context, out []byte, attempt int) time.Duration {
+	if _, secondary := rateLimited(out); secondary {
+		return min(secondaryLimitWait<<attempt, maxRateLimitWait)
+	}
+	if rl, err := GetRateLimit(ctx); err == nil {
+		return time.Until(rl.Exhausted().Reset)
+	}
+	return secondaryLimitWait
+}
+
+// RateLimit is the state of one GitHub API quota.
+type RateLimit struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	Used      int `json:"used"`
+	// ResetUnix is when the quota refills, in Unix seconds.
+	ResetUnix int64     `json:"reset"`
+	Reset     time.Time `json:"-"`
+}
+
+// RateLimits holds the quotas shippr spends: GraphQL for `gh pr`
+// commands, core for REST calls and search for `gh search`.
+type RateLimits struct {
+	Core    RateLimit `json:"core"`
+	GraphQL RateLimit `json:"graphql"`
+	Search  RateLimit `json:"search"`
+}
+
+// GetRateLimit reads the current quotas. The endpoint itself doesn't count
+// against them.
+func GetRateLimit(ctx context.Context) (*RateLimits, error) {
+	out, err := execGH(ctx, "api", "rate_limit", "--jq", ".resources")
+	if err != nil {
+		return nil, newError(ctx, "gh api rate_limit", err, out)
+	}
+	var rl RateLimits
+	if err := json.Unmarshal(out, &rl); err != nil {
+		return nil, fmt.Errorf("parse gh json: %w", err)
+	}
+	for _, r := range []*RateLimit{&rl.Core, &rl.GraphQL, &rl.Search} {
+		r.Reset = time.Unix(r.ResetUnix, 0)
+	}
+	return &rl, nil
+}
+
+// Exhausted returns the quota closest to running out.
+func (r *RateLimits) Exhausted() RateLimit {
+	low := r.GraphQL
+	for _, q := range []RateLimit{r.Core, r.Search} {
+		if q.Limit > 0 && q.Remaining*low.Limit < low.Remaining*q.Limit {
+			low = q
+		}
+	}
+	return low
+}
+
+// String summarises the quota shippr mostly spends (GraphQL), e.g.
+// "API quota 4821/5000, resets 14:05".
+func (r *RateLimits) String() string {
+	q := r.GraphQL
+	return fmt.Sprintf("API quota %d/%d, resets %s", q.Remaining, q.Limit, q.Reset.Local().Format("15:04"))
+}
+
+// limiter bounds concurrent requests and adapts the bound to throttling:
+// each rate-limit response halves it and pauses new requests, and every
+// run of successes as long as the bound raises it by one, up to ceil.
+type limiter struct {
+	mu          sync.Mutex
+	limit, ceil int
+	inflight    int
+	successes   int
+	pauseUntil  time.Time
+	// changed is closed and replaced whenever a slot may have freed up.
+	changed chan struct{}
+}
+
+func newLimiter(start, ceil int) *limiter {
+	return &limiter{limit: min(max(start, 1), ceil), ceil: ceil, changed: make(chan struct{})}
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	var waited time.Time
+	for {
+		l.mu.Lock()
+		if until := l.pauseUntil; until.After(waited) && time.Now().Before(until) {
+			l.mu.Unlock()
+			if err := sleep(ctx, time.Until(until)); err != nil {
+				return err
+			}
+			waited = until
+			continue
+		}
+		if l.inflight < l.limit {
+			l.inflight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	l.notify()
+}
+
+func (l *limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *limiter) throttled(wait time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = max(l.limit/2, 1)
+	l.successes = 0
+	if until := time.Now().Add(wait); until.After(l.pauseUntil) {
+		l.pauseUntil = until
+	}
+}
+
+func (l *limiter) succeeded() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit >= l.ceil {
+		return
+	}
+	l.successes++
+	if l.successes >= l.limit {
+		l.limit++
+		l.successes = 0
+		l.notify()
+	}
+}
+
+// current returns the concurrency bound.
+func (l *limiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+type limiterKey struct{}
+
+func withLimiter(ctx context.Context, l *limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+func limiterFrom(ctx context.Context) *limiter {
+	l, _ := ctx.Value(limiterKey{}).(*limiter)
+	return l
+}
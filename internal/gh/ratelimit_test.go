@@ -0,0 +1,232 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGH stands in for the gh binary: respond answers each invocation and
+// sleeps are recorded instead of waited out.
+type fakeGH struct {
+	mu      sync.Mutex
+	calls   []string
+	sleeps  []time.Duration
+	respond func(args string) ([]byte, error)
+}
+
+func installFakeGH(t *testing.T, respond func(args string) ([]byte, error)) *fakeGH {
+	t.Helper()
+	f := &fakeGH{respond: respond}
+	oldExec, oldSleep := execGH, sleep
+	execGH = func(_ context.Context, args ...string) ([]byte, error) {
+		joined := strings.Join(args, " ")
+		f.mu.Lock()
+		f.calls = append(f.calls, joined)
+		f.mu.Unlock()
+		return f.respond(joined)
+	}
+	sleep = func(ctx context.Context, d time.Duration) error {
+		f.mu.Lock()
+		f.sleeps = append(f.sleeps, d)
+		f.mu.Unlock()
+		return ctx.Err()
+	}
+	t.Cleanup(func() { execGH, sleep = oldExec, oldSleep })
+	return f
+}
+
+var errExit = errors.New("exit status 1")
+
+const secondaryLimit = "HTTP 403: You have exceeded a secondary rate limit. Please wait a few minutes before you try again."
+
+func TestRunGHWaitsOutSecondaryLimit(t *testing.T) {
+	n := 0
+	f := installFakeGH(t, func(string) ([]byte, error) {
+		if n++; n == 1 {
+			return []byte(secondaryLimit), errExit
+		}
+		return []byte("ok"), nil
+	})
+	out, err := runGH(context.Background(), "pr", "list")
+	if err != nil || string(out) != "ok" {
+		t.Fatalf("runGH = %q, %v", out, err)
+	}
+	if len(f.sleeps) != 1 || f.sleeps[0] != secondaryLimitWait {
+		t.Fatalf("sleeps = %v, want [%v]", f.sleeps, secondaryLimitWait)
+	}
+}
+
+func TestRunGHWaitsForPrimaryReset(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Unix()
+	n := 0
+	f := installFakeGH(t, func(args string) ([]byte, error) {
+		if strings.HasPrefix(args, "api rate_limit") {
+			return []byte(fmt.Sprintf(`{"core":{"limit":5000,"remaining":4000,"reset":%d},"graphql":{"limit":5000,"remaining":0,"reset":%d},"search":{"limit":30,"remaining":30,"reset":%d}}`, reset, reset, reset)), nil
+		}
+		if n++; n == 1 {
+			return []byte("GraphQL: API rate limit exceeded for user ID 1."), errExit
+		}
+		return []byte("[]"), nil
+	})
+	if _, err := ListPRs(context.Background(), "o/r", ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sleeps) != 1 || f.sleeps[0] < 25*time.Second || f.sleeps[0] > 31*time.Second {
+		t.Fatalf("sleeps = %v, want about 30s until reset", f.sleeps)
+	}
+}
+
+func TestRunGHGivesUp(t *testing.T) {
+	f := installFakeGH(t, func(string) ([]byte, error) { return []byte(secondaryLimit), errExit })
+	if _, err := runGH(context.Background(), "pr", "list"); err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+	if len(f.calls) != maxRateLimitRetries+1 {
+		t.Fatalf("calls = %d, want %d", len(f.calls), maxRateLimitRetries+1)
+	}
+	// Secondary limits back off exponentially.
+	if f.sleeps[1] != 2*f.sleeps[0] {
+		t.Fatalf("sleeps = %v, want doubling", f.sleeps)
+	}
+}
+
+func TestRunGHDoesNotRetryOtherErrors(t *testing.T) {
+	f := installFakeGH(t, func(string) ([]byte, error) {
+		return []byte("GraphQL: Could not resolve to a Repository"), errExit
+	})
+	if _, err := runGH(context.Background(), "pr", "list"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(f.calls) != 1 || len(f.sleeps) != 0 {
+		t.Fatalf("calls = %v sleeps = %v, want a single attempt", f.calls, f.sleeps)
+	}
+}
+
+func TestLimiterAdapts(t *testing.T) {
+	l := newLimiter(8, 8)
+	l.throttled(0)
+	l.throttled(0)
+	if got := l.current(); got != 2 {
+		t.Fatalf("after two throttles limit = %d, want 2", got)
+	}
+	l.succeeded()
+	l.succeeded()
+	if got := l.current(); got != 3 {
+		t.Fatalf("after a full run of successes limit = %d, want 3", got)
+	}
+	for range 100 {
+		l.succeeded()
+	}
+	if got := l.current(); got != 8 {
+		t.Fatalf("limit should recover to the ceiling, got %d", got)
+	}
+}
+
+func TestListOrgPRsSurvivesThrottling(t *testing.T) {
+	const repos = 12
+	var mu sync.Mutex
+	inflight, peak := 0, 0
+	throttled := map[string]bool{}
+	f := installFakeGH(t, func(args string) ([]byte, error) {
+		switch {
+		case strings.HasPrefix(args, "repo list"):
+			var b strings.Builder
+			b.WriteString("[")
+			for i := range repos {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				fmt.Fprintf(&b, `{"name":"r%d","owner":{"login":"o"}}`, i)
+			}
+			b.WriteString("]")
+			return []byte(b.String()), nil
+		case strings.HasPrefix(args, "api rate_limit"):
+			// Not enough quota for every repo: the scan starts serially.
+			return []byte(`{"graphql":{"limit":5000,"remaining":5,"reset":0}}`), nil
+		}
+		mu.Lock()
+		inflight++
+		peak = max(peak, inflight)
+		repo := strings.Fields(args)[3]
+		first := !throttled[repo]
+		throttled[repo] = true
+		mu.Unlock()
+		defer func() { mu.Lock(); inflight--; mu.Unlock() }()
+		if first && repo == "o/r3" {
+			return []byte(secondaryLimit), errExit
+		}
+		return []byte(`[{"number":1}]`), nil
+	})
+	res, err := ListOrgPRs(context.Background(), "o", 100, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.PRs) != repos || len(res.Failed) != 0 {
+		t.Fatalf("got %d PRs, failed %v; want every repo after retrying", len(res.PRs), res.Failed)
+	}
+	if len(f.sleeps) == 0 {
+		t.Fatal("the throttled repo should have waited before retrying")
+	}
+	if peak > 4 {
+		t.Fatalf("peak concurrency %d; a low quota should keep the scan near serial", peak)
+	}
+}
+
+func TestListOrgPRsReportsFailedRepos(t *testing.T) {
+	installFakeGH(t, func(args string) ([]byte, error) {
+		switch {
+		case strings.HasPrefix(args, "repo list"):
+			return []byte(`[{"name":"a","owner":{"login":"o"}},{"name":"b","owner":{"login":"o"}}]`), nil
+		case strings.Contains(args, "o/b"):
+			return []byte("HTTP 429: Too Many Requests"), errExit
+		}
+		return []byte(`[]`), nil
+	})
+	res, err := ListOrgPRs(context.Background(), "o", 100, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Failed) != 1 || res.Failed[0] != "o/b" {
+		t.Fatalf("Failed = %v, want [o/b]", res.Failed)
+	}
+}
+
+func TestStreamOrgPRsReportsEachRepo(t *testing.T) {
+	installFakeGH(t, func(args string) ([]byte, error) {
+		switch {
+		case strings.HasPrefix(args, "repo list"):
+			return []byte(`[{"name":"a","owner":{"login":"o"}},{"name":"b","owner":{"login":"o"}},{"name":"c","owner":{"login":"o"}}]`), nil
+		case strings.Contains(args, "o/c"):
+			return []byte("HTTP 404: Not Found"), errExit
+		case strings.HasPrefix(args, "pr list"):
+			return []byte(`[{"number":1},{"number":2}]`), nil
+		}
+		return nil, errExit
+	})
+	var batches []OrgBatch
+	res, err := StreamOrgPRs(context.Background(), "o", 100, ListOptions{Limit: 2}, func(b OrgBatch) {
+		batches = append(batches, b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 4 || batches[0].Repo != "" || batches[0].Total != 3 {
+		t.Fatalf("batches = %+v, want an announcement and one per repo", batches)
+	}
+	for i, b := range batches[1:] {
+		if b.Scanned != i+1 || b.Total != 3 {
+			t.Fatalf("batch %d progress = %d/%d", i+1, b.Scanned, b.Total)
+		}
+		if (b.Repo == "o/c") != (b.Err != nil) {
+			t.Fatalf("batch %s err = %v", b.Repo, b.Err)
+		}
+	}
+	if len(res.PRs) != 4 || len(res.Failed) != 1 || len(res.Truncated) != 2 {
+		t.Fatalf("res = %d PRs, failed %v, truncated %v", len(res.PRs), res.Failed, res.Truncated)
+	}
+}
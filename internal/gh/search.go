@@ -0,0 +1,193 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const searchFields = "number,title,author,state,createdAt,updatedAt,labels,isDraft,repository"
+
+type searchPR struct {
+	PR
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+}
+
+// SearchPRs runs `gh search prs` with the given qualifier flags (e.g.
+// "--review-requested=@me") across every repository the user can see.
+// Search results carry fewer fields than ListPRs: no branches, checks,
+// mergeability or review decision.
+func SearchPRs(ctx context.Context, qualifiers []string, limit int) ([]RepoPR, error) {
+	args := append([]string{"search", "prs"}, qualifiers...)
+	args = append(args, "--json", searchFields)
+	if limit > 0 {
+		args = append(args, "--limit", fmt.Sprint(limit))
+	}
+	out, err := runGH(ctx, args...)
+	if err != nil {
+		return nil, newError(ctx, "gh search prs", err, out)
+	}
+	var found []searchPR
+	if err := json.Unmarshal(out, &found); err != nil {
+		return nil, fmt.Errorf("parse gh json: %w", err)
+	}
+	rows := make([]RepoPR, 0, len(found))
+	for _, f := range found {
+		pr := f.PR
+		pr.State = strings.ToUpper(pr.State)
+		rows = append(rows, RepoPR{Repo: f.Repository.NameWithOwner, PR: pr})
+	}
+	return rows, nil
+}
+
+// EnrichError lists the PRs EnrichPRs couldn't load.
+type EnrichError struct {
+	// Failed names each PR as repo#number.
+	Failed []string
+	// Err is the first failure.
+	Err error
+}
+
+func (e *EnrichError) Error() string {
+	return fmt.Sprintf("could not load %d PR(s) (%s): %s", len(e.Failed), strings.Join(e.Failed, ", "), Friendly(e.Err))
+}
+
+func (e *EnrichError) Unwrap() error { return e.Err }
+
+// EnrichPRs replaces search results with full `gh pr view` data (branches,
+// checks, mergeability, review decision). PRs that fail to load keep their
+// search data and are reported in an *EnrichError.
+func EnrichPRs(ctx context.Context, rows []RepoPR) error {
+	sem := make(chan struct{}, max(runtime.NumCPU(), 4))
+	errs := make([]error, len(rows))
+	var wg sync.WaitGroup
+	for i := range rows {
+		wg.Add(1)
+		go func(r *RepoPR, errp *error) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out, err := runGH(ctx, "pr", "view", fmt.Sprint(r.PR.Number), "--repo", r.Repo, "--json", prListFields)
+			if err != nil {
+				*errp = newError(ctx, "gh pr view", err, out)
+				return
+			}
+			var pr PR
+			if err := json.Unmarshal(out, &pr); err != nil {
+				*errp = fmt.Errorf("parse gh json: %w", err)
+				return
+			}
+			r.PR = pr
+		}(&rows[i], &errs[i])
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var enrichErr *EnrichError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if enrichErr == nil {
+			enrichErr = &EnrichError{Err: err}
+		}
+		enrichErr.Failed = append(enrichErr.Failed, fmt.Sprintf("%s#%d", rows[i].Repo, rows[i].PR.Number))
+	}
+	if enrichErr != nil {
+		return enrichErr
+	}
+	return nil
+}
+
+// Mine lists the authenticated user's open PRs across all repositories with
+// full status data, grouped by repository. When some PRs couldn't be loaded
+// the rows come back with an *EnrichError.
+func Mine(ctx context.Context, limit int) ([]RepoPR, error) {
+	rows, err := SearchPRs(ctx, []string{"--author=@me", "--state=open"}, limit)
+	if err != nil {
+		return nil, err
+	}
+	err = EnrichPRs(ctx, rows)
+	var partial *EnrichError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
+	}
+	SortByWait(rows)
+	return rows, err
+}
+
+const (
+	ReasonReviewRequested = "review requested"
+	ReasonAssigned        = "assigned"
+	ReasonMentioned       = "mentioned"
+)
+
+// Inbox gathers open PRs where the authenticated user is a requested
+// reviewer, an assignee or mentioned, grouped by repository and ordered by
+// how long they have been waiting.
+func Inbox(ctx context.Context, limit int) ([]RepoPR, error) {
+	searches := []struct{ reason, flag string }{
+		{ReasonReviewRequested, "--review-requested=@me"},
+		{ReasonAssigned, "--assignee=@me"},
+		{ReasonMentioned, "--mentions=@me"},
+	}
+	byReason := make(map[string][]RepoPR, len(searches))
+	for _, s := range searches {
+		rows, err := SearchPRs(ctx, []string{s.flag, "--state=open"}, limit)
+		if err != nil {
+			return nil, err
+		}
+		byReason[s.reason] = rows
+	}
+	return mergeInbox(byReason, []string{ReasonReviewRequested, ReasonAssigned, ReasonMentioned}), nil
+}
+
+// mergeInbox de-duplicates PRs found by several searches, collecting the
+// reasons each one showed up, then groups by repo and sorts by wait time.
+func mergeInbox(byReason map[string][]RepoPR, order []string) []RepoPR {
+	index := map[string]int{}
+	var merged []RepoPR
+	for _, reason := range order {
+		for _, r := range byReason[reason] {
+			key := fmt.Sprintf("%s#%d", r.Repo, r.PR.Number)
+			if i, ok := index[key]; ok {
+				merged[i].Reasons = append(merged[i].Reasons, reason)
+				continue
+			}
+			r.Reasons = []string{reason}
+			index[key] = len(merged)
+			merged = append(merged, r)
+		}
+	}
+	SortByWait(merged)
+	return merged
+}
+
+// SortByWait groups rows by repository, ordering repositories by their
+// longest-waiting PR and PRs within a repository oldest first.
+func SortByWait(rows []RepoPR) {
+	oldest := map[string]string{}
+	for _, r := range rows {
+		if o, ok := oldest[r.Repo]; !ok || r.PR.CreatedAt < o {
+			oldest[r.Repo] = r.PR.CreatedAt
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Repo != b.Repo {
+			if oldest[a.Repo] != oldest[b.Repo] {
+				return oldest[a.Repo] < oldest[b.Repo]
+			}
+			return a.Repo < b.Repo
+		}
+		return a.PR.CreatedAt < b.PR.CreatedAt
+	})
+}
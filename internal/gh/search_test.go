@@ -0,0 +1,67 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func inboxRow(repo string, number int, created string) RepoPR {
+	return RepoPR{Repo: repo, PR: PR{Number: number, CreatedAt: created}}
+}
+
+func TestMergeInbox(t *testing.T) {
+	byReason := map[string][]RepoPR{
+		ReasonReviewRequested: {
+			inboxRow("acme/web", 7, "2026-03-05T00:00:00Z"),
+			inboxRow("acme/api", 3, "2026-03-01T00:00:00Z"),
+		},
+		ReasonMentioned: {
+			inboxRow("acme/web", 7, "2026-03-05T00:00:00Z"),
+			inboxRow("acme/web", 2, "2026-02-20T00:00:00Z"),
+		},
+	}
+	got := mergeInbox(byReason, []string{ReasonReviewRequested, ReasonAssigned, ReasonMentioned})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 de-duplicated rows, got %d", len(got))
+	}
+	// acme/web waits longest (#2 since Feb 20), so it comes first, oldest PR first.
+	want := []struct {
+		repo   string
+		number int
+	}{{"acme/web", 2}, {"acme/web", 7}, {"acme/api", 3}}
+	for i, w := range want {
+		if got[i].Repo != w.repo || got[i].PR.Number != w.number {
+			t.Fatalf("row %d: got %s#%d, want %s#%d", i, got[i].Repo, got[i].PR.Number, w.repo, w.number)
+		}
+	}
+	if r := got[1].Reasons; len(r) != 2 || r[0] != ReasonReviewRequested || r[1] != ReasonMentioned {
+		t.Fatalf("unexpected reasons for acme/web#7: %v", r)
+	}
+}
+
+func TestEnrichPRsReportsFailures(t *testing.T) {
+	installFakeGH(t, func(args string) ([]byte, error) {
+		if strings.Contains(args, "view 2 ") {
+			return []byte("HTTP 404: Not Found"), errExit
+		}
+		return []byte(`{"number":1,"title":"full","headRefName":"feature"}`), nil
+	})
+	rows := []RepoPR{
+		{Repo: "o/r", PR: PR{Number: 1, Title: "search"}},
+		{Repo: "o/r", PR: PR{Number: 2, Title: "search"}},
+	}
+	err := EnrichPRs(context.Background(), rows)
+	var enrichErr *EnrichError
+	if !errors.As(err, &enrichErr) || !slices.Equal(enrichErr.Failed, []string{"o/r#2"}) {
+		t.Fatalf("err = %v, want an EnrichError naming o/r#2", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want it to wrap ErrNotFound", err)
+	}
+	if rows[0].PR.HeadRefName != "feature" || rows[1].PR.Title != "search" {
+		t.Errorf("rows = %+v, want #1 enriched and #2 left as found", rows)
+	}
+}
@@ -0,0 +1,319 @@
+// Package gitx drives local git operations that GitHub's API can't do for
+// us, such as rebasing a conflicting PR branch in a scratch checkout.
+package gitx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"git-shippr/internal/dryrun"
+)
+
+// Checkout is a scratch checkout with a PR branch checked out: a worktree
+// of the user's own clone when there is one, a temporary clone otherwise.
+type Checkout struct {
+	Dir    string
+	Repo   string
+	Number int
+	Base   string
+	// Remote is the name of Repo's remote in Dir.
+	Remote string
+
+	// head is the PR branch and headURL its repository, a fork for
+	// cross-repository PRs; headSHA is the commit the lease expects there.
+	head, headURL, headSHA string
+	// worktreeOf is the clone Dir was added to as a worktree.
+	worktreeOf string
+}
+
+// ErrEmptyCommit means the commit being rebased has no changes left, e.g.
+// because the base already has them. Skip drops it.
+var ErrEmptyCommit = errors.New("nothing left to commit: the base already has these changes")
+
+func run(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if err != nil {
+		return out.String(), fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, out.String())
+	}
+	return out.String(), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	// Never open an editor: rebase --continue and revert must not block.
+	return run(ctx, dir, []string{"GIT_EDITOR=true", "GIT_TERMINAL_PROMPT=0"}, "git", args...)
+}
+
+// CheckoutPR checks out PR number's head commit, detached, from the
+// branch head of headRepo. A clone of repo in the current directory gets a
+// temporary worktree; without one repo is cloned into a temporary
+// directory.
+func CheckoutPR(ctx context.Context, repo string, number int, base, headRepo, head string) (*Checkout, error) {
+	if headRepo == "" {
+		return nil, fmt.Errorf("the repository of #%d's branch no longer exists", number)
+	}
+	dir, err := os.MkdirTemp("", fmt.Sprintf("shippr-%s-%d-", strings.ReplaceAll(repo, "/", "-"), number))
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkout{Dir: dir, Repo: repo, Number: number, Base: base, head: head}
+	if top, remote, ok := localClone(ctx, repo); ok {
+		c.Remote, c.worktreeOf = remote, top
+	} else {
+		c.Remote = "origin"
+		if _, err := run(ctx, "", nil, "gh", "repo", "clone", repo, dir, "--", "--filter=blob:none", "--quiet", "--no-checkout"); err != nil {
+			c.Remove()
+			return nil, err
+		}
+	}
+	src := dir
+	if c.worktreeOf != "" {
+		src = c.worktreeOf
+	}
+	if err := c.fetch(ctx, src, headRepo); err != nil {
+		c.Remove()
+		return nil, err
+	}
+	if c.worktreeOf != "" {
+		_, err = git(ctx, c.worktreeOf, "worktree", "add", "--detach", dir, c.headSHA)
+	} else {
+		_, err = git(ctx, dir, "checkout", "-q", "--detach", c.headSHA)
+	}
+	if err != nil {
+		c.Remove()
+		return nil, err
+	}
+	return c, nil
+}
+
+// fetch updates the base branch and records the PR's head commit, fetched
+// from headRepo at the same host and in the same URL form as c.Remote.
+func (c *Checkout) fetch(ctx context.Context, dir, headRepo string) error {
+	if _, err := git(ctx, dir, "fetch", "-q", c.Remote, c.Base); err != nil {
+		return err
+	}
+	url, err := git(ctx, dir, "remote", "get-url", c.Remote)
+	if err != nil {
+		return err
+	}
+	c.headURL = repoURL(strings.TrimSpace(url), c.Repo, headRepo)
+	if _, err := git(ctx, dir, "fetch", "-q", c.headURL, "refs/heads/"+c.head); err != nil {
+		return err
+	}
+	sha, err := git(ctx, dir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+	c.headSHA = strings.TrimSpace(sha)
+	return nil
+}
+
+// localClone finds a clone of repo around the current directory and the
+// name of its remote for repo.
+func localClone(ctx context.Context, repo string) (top, remote string, ok bool) {
+	out, err := git(ctx, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", false
+	}
+	top = strings.TrimSpace(out)
+	out, err = git(ctx, top, "remote", "-v")
+	if err != nil {
+		return "", "", false
+	}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && f[2] == "(fetch)" && sameRepo(f[1], repo) {
+			return top, f[0], true
+		}
+	}
+	return "", "", false
+}
+
+// sameRepo reports whether a remote URL, in HTTPS or SSH form, points to
+// repo.
+func sameRepo(url, repo string) bool {
+	url = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(url), "/"), ".git")
+	repo = strings.ToLower(repo)
+	return strings.HasSuffix(url, "/"+repo) || strings.HasSuffix(url, ":"+repo)
+}
+
+// repoURL is url, a remote URL of repo, rewritten to point to other.
+func repoURL(url, repo, other string) string {
+	i := strings.LastIndex(strings.ToLower(url), strings.ToLower(repo))
+	if i < 0 {
+		return url
+	}
+	return url[:i] + other + url[i+len(repo):]
+}
+
+// CloneBase clones repo into a fresh temporary directory with base checked
+// out, for work that starts from the base branch such as reverts.
+func CloneBase(ctx context.Context, repo, base string) (*Checkout, error) {
+	dir, err := os.MkdirTemp("", fmt.Sprintf("shippr-%s-", strings.ReplaceAll(repo, "/", "-")))
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkout{Dir: dir, Repo: repo, Base: base, Remote: "origin"}
+	if _, err := run(ctx, "", nil, "gh", "repo", "clone", repo, dir, "--", "--filter=blob:none", "--quiet", "--branch", base); err != nil {
+		c.Remove()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Revert creates branch from <remote>/<base> with a commit reverting sha. For
+// merge commits the first parent (the base side) is kept. On conflicts the
+// revert is abandoned and the conflicted paths are returned with an error.
+func (c *Checkout) Revert(ctx context.Context, sha, branch string) ([]string, error) {
+	if _, err := git(ctx, c.Dir, "checkout", "-q", "-b", branch, c.Remote+"/"+c.Base); err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, c.Dir, "rev-list", "--parents", "-n", "1", sha)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"revert", "--no-edit"}
+	if len(strings.Fields(out)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	if _, err := git(ctx, c.Dir, append(args, sha)...); err != nil {
+		files, _ := c.Conflicts(ctx)
+		_, _ = git(ctx, c.Dir, "revert", "--abort")
+		if len(files) > 0 {
+			return files, fmt.Errorf("reverting %s conflicts with later changes in %s", sha, strings.Join(files, ", "))
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+// PushBranch pushes a new branch to Repo.
+func (c *Checkout) PushBranch(ctx context.Context, branch string) error {
+	if dryrun.Intercept("git", "-C", c.Dir, "push", "-u", c.Remote, branch) {
+		return nil
+	}
+	_, err := git(ctx, c.Dir, "push", "-u", c.Remote, branch)
+	return err
+}
+
+// Rebase rebases the PR branch onto <remote>/<base>. When the rebase stops
+// on conflicts it returns the conflicted paths and a nil error.
+func (c *Checkout) Rebase(ctx context.Context) ([]string, error) {
+	if _, err := git(ctx, c.Dir, "rebase", c.Remote+"/"+c.Base); err != nil {
+		return c.stopped(ctx, err)
+	}
+	return nil, nil
+}
+
+// Continue stages the previously conflicted files and continues the rebase.
+// It refuses while any of them still contains conflict markers.
+func (c *Checkout) Continue(ctx context.Context) ([]string, error) {
+	files, err := c.Conflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var unresolved []string
+	for _, f := range files {
+		if hasConflictMarkers(filepath.Join(c.Dir, f)) {
+			unresolved = append(unresolved, f)
+		}
+	}
+	if len(unresolved) > 0 {
+		return unresolved, fmt.Errorf("conflict markers remain in %s", strings.Join(unresolved, ", "))
+	}
+	if len(files) > 0 {
+		if _, err := git(ctx, c.Dir, append([]string{"add", "--"}, files...)...); err != nil {
+			return files, err
+		}
+	}
+	if _, err := git(ctx, c.Dir, "rebase", "--continue"); err != nil {
+		return c.stopped(ctx, err)
+	}
+	return nil, nil
+}
+
+// Skip drops the commit the rebase stopped at and carries on, e.g. after
+// Continue returned ErrEmptyCommit.
+func (c *Checkout) Skip(ctx context.Context) ([]string, error) {
+	if _, err := git(ctx, c.Dir, "rebase", "--skip"); err != nil {
+		return c.stopped(ctx, err)
+	}
+	return nil, nil
+}
+
+func (c *Checkout) stopped(ctx context.Context, rebaseErr error) ([]string, error) {
+	files, err := c.Conflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+	// The merge backend says "nothing to commit", the apply backend "No
+	// changes".
+	if msg := rebaseErr.Error(); strings.Contains(msg, "nothing to commit") || strings.Contains(msg, "No changes") {
+		return nil, ErrEmptyCommit
+	}
+	return nil, rebaseErr
+}
+
+// Conflicts lists paths with unresolved merge conflicts.
+func (c *Checkout) Conflicts(ctx context.Context) ([]string, error) {
+	out, err := git(ctx, c.Dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// Abort abandons an in-progress rebase.
+func (c *Checkout) Abort(ctx context.Context) error {
+	_, err := git(ctx, c.Dir, "rebase", "--abort")
+	return err
+}
+
+// Push force-pushes the rebased commits to the PR branch in its own
+// repository, refusing if the branch moved since it was fetched.
+func (c *Checkout) Push(ctx context.Context) error {
+	args := []string{"push", "--force-with-lease=refs/heads/" + c.head + ":" + c.headSHA, c.headURL, "HEAD:refs/heads/" + c.head}
+	if dryrun.Intercept("git", append([]string{"-C", c.Dir}, args...)...) {
+		return nil
+	}
+	_, err := git(ctx, c.Dir, args...)
+	return err
+}
+
+// Remove deletes the scratch checkout, unregistering it from the clone it
+// is a worktree of.
+func (c *Checkout) Remove() error {
+	if c.worktreeOf != "" {
+		// Not fatal: the directory is deleted below, and git prunes
+		// worktrees whose directory is gone.
+		_, _ = git(context.Background(), c.worktreeOf, "worktree", "remove", "--force", c.Dir)
+	}
+	return os.RemoveAll(c.Dir)
+}
+
+func hasConflictMarkers(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("<<<<<<< ")) || bytes.HasPrefix(line, []byte(">>>>>>> ")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package gitx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sh(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// conflictingClone builds an origin where main and feature both edit f.txt
+// and returns a clone with feature checked out.
+func conflictingClone(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	if err := os.Mkdir(origin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sh(t, origin, "init", "-q", "-b", "main")
+	write(t, filepath.Join(origin, "f.txt"), "base\n")
+	sh(t, origin, "add", ".")
+	sh(t, origin, "commit", "-qm", "base")
+	sh(t, origin, "checkout", "-qb", "feature")
+	write(t, filepath.Join(origin, "f.txt"), "feature\n")
+	sh(t, origin, "commit", "-qam", "feature")
+	sh(t, origin, "checkout", "-q", "main")
+	write(t, filepath.Join(origin, "f.txt"), "main\n")
+	sh(t, origin, "commit", "-qam", "main")
+
+	clone := filepath.Join(root, "clone")
+	sh(t, root, "clone", "-q", origin, clone)
+	sh(t, clone, "checkout", "-q", "feature")
+	return clone
+}
+
+func TestRebaseConflictFlow(t *testing.T) {
+	clone := conflictingClone(t)
+	ctx := context.Background()
+	c := &Checkout{Dir: clone, Base: "main", Remote: "origin"}
+
+	files, err := c.Rebase(ctx)
+	if err != nil {
+		t.Fatalf("rebase: %v", err)
+	}
+	if len(files) != 1 || files[0] != "f.txt" {
+		t.Fatalf("expected conflict in f.txt, got %v", files)
+	}
+
+	if _, err := c.Continue(ctx); err == nil {
+		t.Fatalf("continue should refuse while conflict markers remain")
+	}
+
+	write(t, filepath.Join(clone, "f.txt"), "resolved\n")
+	files, err = c.Continue(ctx)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("continue after resolving: files=%v err=%v", files, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(clone, "f.txt"))
+	if string(data) != "resolved\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	clone := conflictingClone(t)
+	ctx := context.Background()
+	c := &Checkout{Dir: clone, Base: "main", Remote: "origin"}
+	if _, err := c.Rebase(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Abort(ctx); err != nil {
+		t.Fatalf("abort: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(clone, "f.txt"))
+	if string(data) != "feature\n" {
+		t.Fatalf("abort should restore the branch, got %q", data)
+	}
+}
+
+func revParse(t *testing.T, dir, rev string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "rev-parse", rev).Output()
+	if err != nil {
+		t.Fatalf("rev-parse %s: %v", rev, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestRevertMergeAndSquashCommits(t *testing.T) {
+	clone := conflictingClone(t)
+	origin := filepath.Join(filepath.Dir(clone), "origin")
+	// A merge commit and a plain (squash-style) commit land on main.
+	sh(t, origin, "checkout", "-qb", "extra", "main")
+	write(t, filepath.Join(origin, "g.txt"), "merged\n")
+	sh(t, origin, "add", ".")
+	sh(t, origin, "commit", "-qm", "extra")
+	sh(t, origin, "checkout", "-q", "main")
+	sh(t, origin, "merge", "-q", "--no-ff", "-m", "Merge extra", "extra")
+	merge := revParse(t, origin, "HEAD")
+	write(t, filepath.Join(origin, "h.txt"), "squashed\n")
+	sh(t, origin, "add", ".")
+	sh(t, origin, "commit", "-qm", "squash")
+	squash := revParse(t, origin, "HEAD")
+	sh(t, clone, "fetch", "-q", "origin")
+
+	ctx := context.Background()
+	c := &Checkout{Dir: clone, Base: "main", Remote: "origin"}
+	if _, err := c.Revert(ctx, merge, "revert-1"); err != nil {
+		t.Fatalf("revert merge: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "g.txt")); !os.IsNotExist(err) {
+		t.Fatalf("g.txt should be gone after reverting the merge")
+	}
+	if _, err := c.Revert(ctx, squash, "revert-2"); err != nil {
+		t.Fatalf("revert squash: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "h.txt")); !os.IsNotExist(err) {
+		t.Fatalf("h.txt should be gone after reverting the squash commit")
+	}
+}
+
+// forkedPR sets up the PR from conflictingClone as a cross-repository one:
+// upstream o/r, the branch in fork/r, and the user's clone of o/r as the
+// current directory. It returns the clone and the fork.
+func forkedPR(t *testing.T) (clone, fork string) {
+	root := filepath.Dir(conflictingClone(t))
+	origin := filepath.Join(root, "origin")
+	fork = filepath.Join(root, "fork", "r")
+	sh(t, root, "clone", "-q", "--bare", origin, filepath.Join(root, "o", "r"))
+	sh(t, root, "clone", "-q", "--bare", origin, fork)
+	clone = filepath.Join(root, "work")
+	sh(t, root, "clone", "-q", filepath.Join(root, "o", "r"), clone)
+	t.Chdir(clone)
+	return clone, fork
+}
+
+func TestCheckoutPRUsesWorktreeAndPushesToFork(t *testing.T) {
+	clone, fork := forkedPR(t)
+	ctx := context.Background()
+	c, err := CheckoutPR(ctx, "o/r", 1, "main", "fork/r", "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.worktreeOf != clone || c.Remote != "origin" {
+		t.Fatalf("checkout = %+v, want a worktree of %s", c, clone)
+	}
+	if files, err := c.Rebase(ctx); err != nil || len(files) != 1 {
+		t.Fatalf("rebase: files=%v err=%v", files, err)
+	}
+	write(t, filepath.Join(c.Dir, "f.txt"), "resolved\n")
+	if _, err := c.Continue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Push(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := revParse(t, fork, "feature"), revParse(t, c.Dir, "HEAD"); got != want {
+		t.Errorf("fork feature = %s, want the rebased %s", got, want)
+	}
+	if err := c.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := exec.Command("git", "-C", clone, "worktree", "list").Output()
+	if strings.Contains(string(out), c.Dir) {
+		t.Errorf("worktree still registered:\n%s", out)
+	}
+}
+
+func TestPushRefusesMovedBranch(t *testing.T) {
+	clone, fork := forkedPR(t)
+	ctx := context.Background()
+	c, err := CheckoutPR(ctx, "o/r", 1, "main", "fork/r", "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Remove()
+	// The author pushes again while the rebase is in progress.
+	sh(t, clone, "checkout", "-q", "-b", "other", c.headSHA)
+	write(t, filepath.Join(clone, "g.txt"), "more\n")
+	sh(t, clone, "add", ".")
+	sh(t, clone, "commit", "-qm", "more")
+	sh(t, clone, "push", "-q", fork, "HEAD:refs/heads/feature")
+	if _, err := c.Rebase(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Abort(ctx)
+	if err := c.Push(ctx); err == nil {
+		t.Fatal("push should fail when the branch moved since it was fetched")
+	}
+}
+
+func TestSkipEmptyCommit(t *testing.T) {
+	clone := conflictingClone(t)
+	// The apply backend stops on commits left empty by the resolution.
+	sh(t, clone, "config", "rebase.backend", "apply")
+	ctx := context.Background()
+	c := &Checkout{Dir: clone, Base: "main", Remote: "origin"}
+	if _, err := c.Rebase(ctx); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(clone, "f.txt"), "main\n")
+	if _, err := c.Continue(ctx); !errors.Is(err, ErrEmptyCommit) {
+		t.Fatalf("continue = %v, want ErrEmptyCommit", err)
+	}
+	if files, err := c.Skip(ctx); err != nil || len(files) != 0 {
+		t.Fatalf("skip: files=%v err=%v", files, err)
+	}
+	if got, want := revParse(t, clone, "HEAD"), revParse(t, clone, "origin/main"); got != want {
+		t.Errorf("HEAD = %s, want origin/main %s once the empty commit is dropped", got, want)
+	}
+}
+
+func TestRepoURL(t *testing.T) {
+	for _, tc := range []struct{ url, want string }{
+		{"https://github.com/o/r.git", "https://github.com/fork/r.git"},
+		{"git@github.com:O/R.git", "git@github.com:fork/r.git"},
+		{"ssh://git@ghe.example.com/o/r", "ssh://git@ghe.example.com/fork/r"},
+	} {
+		if !sameRepo(tc.url, "o/r") {
+			t.Errorf("sameRepo(%q, o/r) = false", tc.url)
+		}
+		if got := repoURL(tc.url, "o/r", "fork/r"); got != tc.want {
+			t.Errorf("repoURL(%q) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+	if sameRepo("https://github.com/o/r-docs.git", "o/r") {
+		t.Error("o/r-docs is not o/r")
+	}
+}
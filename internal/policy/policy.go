@@ -0,0 +1,343 @@
+// Package policy evaluates automation rules against PRs: each rule pairs a
+// condition, written in the query language plus a few policy-only terms,
+// with actions to take on the PRs that meet it. For example
+//
+//	{
+//	  "name": "Automerge Renovate patches",
+//	  "if": "author:renovate[bot] label:automerge checks:passing update:patch",
+//	  "then": {"merge": "squash", "delete_branch": true}
+//	}
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"git-shippr/internal/bots"
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+)
+
+type Policy struct {
+	Rules []*Rule `json:"rules"`
+}
+
+type Rule struct {
+	Name string  `json:"name"`
+	If   string  `json:"if"`
+	Then Actions `json:"then"`
+
+	query *query.Query
+	terms []term
+}
+
+// Actions are applied in field order; a merge or close ends the run for
+// that PR.
+type Actions struct {
+	Label   []string `json:"label,omitempty"`
+	Approve bool     `json:"approve,omitempty"`
+	// Merge is the merge strategy: squash, rebase or merge.
+	Merge        string `json:"merge,omitempty"`
+	DeleteBranch bool   `json:"delete_branch,omitempty"`
+	Close        bool   `json:"close,omitempty"`
+}
+
+func (a Actions) String() string {
+	var parts []string
+	if len(a.Label) > 0 {
+		parts = append(parts, "label "+strings.Join(a.Label, ", "))
+	}
+	if a.Approve {
+		parts = append(parts, "approve")
+	}
+	if a.Merge != "" {
+		m := a.Merge + "-merge"
+		if a.DeleteBranch {
+			m += " and delete branch"
+		}
+		parts = append(parts, m)
+	}
+	if a.Close {
+		parts = append(parts, "close")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Terms only policies understand, on top of the query language.
+var policyKeys = map[string]bool{"update": true, "files": true, "lines": true}
+
+type term struct {
+	raw    string
+	key    string
+	value  string
+	negate bool
+	// lines terms
+	op string
+	n  int
+}
+
+// Load reads and compiles the policy file at p.
+func Load(p string) (*Policy, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	pol, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", p, err)
+	}
+	return pol, nil
+}
+
+// Parse compiles a JSON policy, rejecting rules shippr can't evaluate or
+// carry out.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Rules) == 0 {
+		return nil, errors.New("no rules")
+	}
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+	}
+	return &p, nil
+}
+
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.If) == "" {
+		return errors.New(`missing "if" condition`)
+	}
+	switch a := r.Then; {
+	case a.String() == "":
+		return errors.New(`"then" has no actions`)
+	case a.Merge != "" && a.Merge != "squash" && a.Merge != "rebase" && a.Merge != "merge":
+		return fmt.Errorf("unknown merge strategy %q (want squash, rebase or merge)", a.Merge)
+	case a.Merge != "" && a.Close:
+		return errors.New("a rule can't both merge and close")
+	case a.DeleteBranch && a.Merge == "":
+		return errors.New("delete_branch needs merge")
+	}
+	var rest []string
+	for _, tok := range query.Tokenize(r.If) {
+		t := term{raw: tok}
+		s := tok
+		if strings.HasPrefix(s, "-") && len(s) > 1 {
+			t.negate, s = true, s[1:]
+		}
+		k, v, _ := strings.Cut(s, ":")
+		if !policyKeys[strings.ToLower(k)] {
+			rest = append(rest, tok)
+			continue
+		}
+		t.key, t.value = strings.ToLower(k), strings.ToLower(strings.Trim(v, `"`))
+		if err := t.validate(); err != nil {
+			return err
+		}
+		r.terms = append(r.terms, t)
+	}
+	q, err := query.Parse(strings.Join(rest, " "))
+	if err != nil {
+		return err
+	}
+	if q.Text() != "" {
+		// The picker treats these as free text; in a rule they are most
+		// likely a typo.
+		return fmt.Errorf("unknown condition %q", q.Text())
+	}
+	r.query = q
+	return nil
+}
+
+func (t *term) validate() error {
+	switch t.key {
+	case "update":
+		if _, ok := bots.ParseUpdate(t.value); !ok && t.value != bots.Unknown.String() {
+			return fmt.Errorf("update: unknown value %q (want patch, minor, major or unknown)", t.value)
+		}
+	case "files":
+		if !validGlob(t.value) {
+			return fmt.Errorf("files: invalid pattern %q", t.value)
+		}
+	case "lines":
+		t.op = "<="
+		v := t.value
+		for _, op := range []string{">=", "<=", ">", "<"} {
+			if strings.HasPrefix(v, op) {
+				t.op, v = op, v[len(op):]
+				break
+			}
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("lines: invalid value %q (e.g. lines:<=50)", t.value)
+		}
+		t.n = n
+	}
+	return nil
+}
+
+// needsDetails reports whether deciding t for pr takes its details.
+func (t term) needsDetails(pr PR) bool {
+	switch t.key {
+	case "files", "lines":
+		return true
+	case "update":
+		// Only the body of a Renovate PR tells patch from minor.
+		return bots.NeedsBody(pr.PR.Title)
+	}
+	return false
+}
+
+// PR is what rules are evaluated against.
+type PR struct {
+	gh.RepoPR
+	// Details is only needed for some terms, e.g. files:; see NeedsDetails.
+	Details *gh.PRDetails
+}
+
+// Match is a rule's verdict on one PR.
+type Match struct {
+	Rule *Rule
+	// Failed lists the conditions the PR doesn't meet, as written in the
+	// rule; it is empty when the rule matched.
+	Failed []string
+}
+
+func (m Match) Matched() bool { return len(m.Failed) == 0 }
+
+// Evaluate checks every rule against pr, in order.
+func (p *Policy) Evaluate(pr PR, env query.Env) []Match {
+	out := make([]Match, 0, len(p.Rules))
+	for _, r := range p.Rules {
+		out = append(out, Match{Rule: r, Failed: r.failing(pr, env, true)})
+	}
+	return out
+}
+
+// NeedsDetails reports whether evaluating pr needs its details: some rule
+// has a term that reads them and its other conditions hold. Fetching
+// details costs a request per PR, so it's skipped when they can't matter.
+func (p *Policy) NeedsDetails(pr PR, env query.Env) bool {
+	if pr.Details != nil {
+		return false
+	}
+	for _, r := range p.Rules {
+		if slices.ContainsFunc(r.terms, func(t term) bool { return t.needsDetails(pr) }) &&
+			len(r.failing(pr, env, false)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// failing lists the rule's conditions pr doesn't meet, leaving out those
+// on details unless withDetails is set.
+func (r *Rule) failing(pr PR, env query.Env, withDetails bool) []string {
+	failed := r.query.Failing(pr.RepoPR, env)
+	for _, t := range r.terms {
+		if !withDetails && t.needsDetails(pr) {
+			continue
+		}
+		if t.match(pr) == t.negate {
+			failed = append(failed, t.raw)
+		}
+	}
+	return failed
+}
+
+func (t term) match(pr PR) bool {
+	switch t.key {
+	case "update":
+		body := ""
+		if pr.Details != nil {
+			body = pr.Details.Body
+		}
+		b, ok := bots.ParseDetails(pr.PR.Title, body)
+		if !ok {
+			return t.value == bots.Unknown.String()
+		}
+		return b.Update.String() == t.value
+	case "files":
+		// Every changed file must match, e.g. files:docs/** for docs-only PRs.
+		if pr.Details == nil || len(pr.Details.Files) == 0 {
+			return false
+		}
+		for _, f := range pr.Details.Files {
+			if !matchGlob(t.value, strings.ToLower(f.Path)) {
+				return false
+			}
+		}
+		return true
+	case "lines":
+		if pr.Details == nil {
+			return false
+		}
+		n := pr.Details.Additions + pr.Details.Deletions
+		switch t.op {
+		case "<":
+			return n < t.n
+		case ">":
+			return n > t.n
+		case ">=":
+			return n >= t.n
+		}
+		return n <= t.n
+	}
+	return false
+}
+
+// matchGlob is path.Match with "**" as a whole path element matching any
+// number of directories, so docs/** covers docs/guide/setup.md as well.
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// A trailing ** needs something below it, as in .gitignore.
+			start := 0
+			if len(pattern) == 1 {
+				start = 1
+			}
+			for i := start; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validGlob reports whether every element of pattern is a valid
+// path.Match pattern.
+func validGlob(pattern string) bool {
+	for _, elem := range strings.Split(pattern, "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return false
+		}
+	}
+	return pattern != ""
+}
@@ -0,0 +1,166 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"git-shippr/internal/gh"
+	"git-shippr/internal/query"
+)
+
+var env = query.Env{Me: "me", Now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+
+const fixturePolicy = `{"rules": [
+	{
+		"name": "Automerge Renovate patches",
+		"if": "author:renovate[bot] label:automerge checks:passing update:patch",
+		"then": {"merge": "squash", "delete_branch": true}
+	},
+	{
+		"name": "Approve small docs changes",
+		"if": "files:docs/** lines:<=20 -is:draft",
+		"then": {"approve": true, "label": ["docs"]}
+	},
+	{
+		"name": "Close abandoned drafts",
+		"if": "is:draft updated:>90d",
+		"then": {"close": true}
+	}
+]}`
+
+func renovatePR(title string, labels ...string) PR {
+	pr := gh.PR{
+		Number:            7,
+		Title:             title,
+		State:             "OPEN",
+		Author:            gh.Actor{Login: "app/renovate", IsBot: true},
+		UpdatedAt:         env.Now.Add(-time.Hour).Format(time.RFC3339),
+		StatusCheckRollup: []gh.Check{{Status: "COMPLETED", Conclusion: "SUCCESS"}},
+	}
+	for _, l := range labels {
+		pr.Labels = append(pr.Labels, gh.Label{Name: l})
+	}
+	return PR{RepoPR: gh.RepoPR{Repo: "o/r", PR: pr}}
+}
+
+func docsPR(lines int, files ...string) PR {
+	p := PR{RepoPR: gh.RepoPR{Repo: "o/r", PR: gh.PR{
+		Number:    9,
+		Title:     "Fix typo",
+		State:     "OPEN",
+		Author:    gh.Actor{Login: "ann"},
+		UpdatedAt: env.Now.Format(time.RFC3339),
+	}}}
+	p.Details = &gh.PRDetails{Number: 9, Additions: lines}
+	p.Details.Files = slices.Grow(p.Details.Files, len(files))[:len(files)]
+	for i, f := range files {
+		p.Details.Files[i].Path = f
+	}
+	return p
+}
+
+func matched(ms []Match) []string {
+	var names []string
+	for _, m := range ms {
+		if m.Matched() {
+			names = append(names, m.Rule.Name)
+		}
+	}
+	return names
+}
+
+func TestEvaluate(t *testing.T) {
+	pol, err := Parse([]byte(fixturePolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := renovatePR("Update dependency eslint to v8.56.1", "automerge")
+	patch.Details = &gh.PRDetails{Body: "| eslint | `8.56.0` -> `8.56.1` |"}
+	tests := []struct {
+		name string
+		pr   PR
+		want string
+	}{
+		{"renovate patch", patch, "Automerge Renovate patches"},
+		{"renovate major", renovatePR("Update dependency eslint to v9", "automerge"), ""},
+		{"docs only", docsPR(3, "docs/intro.md", "docs/setup.md"), "Approve small docs changes"},
+		{"nested docs", docsPR(3, "docs/guide/install/linux.md"), "Approve small docs changes"},
+		{"docs and code", docsPR(3, "docs/intro.md", "main.go"), ""},
+		{"large docs change", docsPR(500, "docs/intro.md"), ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(matched(pol.Evaluate(tt.pr, env)), ","); got != tt.want {
+			t.Errorf("%s: matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateExplainsMismatches(t *testing.T) {
+	pol, err := Parse([]byte(fixturePolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := pol.Evaluate(renovatePR("Update dependency react to v19"), env)
+	if got := strings.Join(ms[0].Failed, " "); got != "label:automerge update:patch" {
+		t.Errorf("Failed = %q, want the label and update conditions", got)
+	}
+}
+
+func TestNeedsDetails(t *testing.T) {
+	pol, err := Parse([]byte(fixturePolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pol.NeedsDetails(renovatePR("Update dependency eslint to v8.56.1", "automerge"), env) {
+		t.Error("a Renovate title without the old version needs the PR body")
+	}
+	// The docs rule can still match any non-draft PR, so details are needed
+	// unless the PR is a draft.
+	draft := renovatePR("Update dependency eslint to v9")
+	draft.PR.IsDraft = true
+	if pol.NeedsDetails(draft, env) {
+		t.Error("no rule reading details can match a draft without the label")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"docs/*", "docs/intro.md", true},
+		{"docs/*", "docs/guide/intro.md", false},
+		{"docs/**", "docs/guide/intro.md", true},
+		{"docs/**", "docs", false},
+		{"**/*.md", "README.md", true},
+		{"**/*.md", "a/b/c.md", true},
+		{"src/**/test/*.go", "src/test/x.go", true},
+		{"src/**/test/*.go", "src/a/b/test/x.go", true},
+		{"src/**/test/*.go", "src/a/b/x.go", false},
+	} {
+		if got := matchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseRejectsBadRules(t *testing.T) {
+	for _, tc := range []struct{ rule, want string }{
+		{`{"if": "is:open", "then": {}}`, "no actions"},
+		{`{"if": "", "then": {"approve": true}}`, "missing"},
+		{`{"if": "is:open", "then": {"merge": "fast-forward"}}`, "merge strategy"},
+		{`{"if": "is:open", "then": {"merge": "squash", "close": true}}`, "both"},
+		{`{"if": "is:open", "then": {"delete_branch": true, "approve": true}}`, "needs merge"},
+		{`{"if": "is:open lable:docs", "then": {"approve": true}}`, "unknown condition"},
+		{`{"if": "update:huge", "then": {"approve": true}}`, "update"},
+		{`{"if": "lines:lots", "then": {"approve": true}}`, "lines"},
+		{`{"if": "files:docs/[", "then": {"approve": true}}`, "invalid pattern"},
+	} {
+		_, err := Parse([]byte(`{"rules": [` + tc.rule + `]}`))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tc.rule, err, tc.want)
+		}
+	}
+}
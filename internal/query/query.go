@@ -0,0 +1,347 @@
+// Package query implements the PR filter language typed into the picker,
+// stored in saved views and used for the conditions of policy rules, e.g.
+//
+//	author:me label:deps -is:draft checks:passing age:>3d
+//
+// Terms are ANDed together; a leading "-" negates a term. Words that are not
+// key:value terms (or use an unknown key) are kept as free text.
+package query
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"git-shippr/internal/gh"
+)
+
+// Env carries the context a query is evaluated in.
+type Env struct {
+	Me  string
+	Now time.Time
+}
+
+type term struct {
+	// raw is the term as written, for explanations.
+	raw    string
+	key    string
+	value  string
+	negate bool
+	// age terms
+	op  string
+	dur time.Duration
+}
+
+type Query struct {
+	terms []term
+	text  []string
+}
+
+var keys = map[string]bool{
+	"author":           true,
+	"label":            true,
+	"is":               true,
+	"checks":           true,
+	"review":           true,
+	"review-requested": true,
+	"age":              true,
+	"updated":          true,
+	"repo":             true,
+	"head":             true,
+	"base":             true,
+}
+
+var allowed = map[string][]string{
+	"is":     {"open", "closed", "merged", "draft", "conflict", "mergeable", "approved", "bot"},
+	"checks": {"passing", "failing", "pending", "none"},
+	"review": {"approved", "changes", "required", "none"},
+}
+
+// Parse parses a query string. It only fails for known keys with invalid
+// values; anything it doesn't recognise becomes free text.
+func Parse(s string) (*Query, error) {
+	q := &Query{}
+	for _, tok := range Tokenize(s) {
+		raw := tok
+		negate := false
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			negate = true
+			tok = tok[1:]
+		}
+		k, v, ok := strings.Cut(tok, ":")
+		k = strings.ToLower(k)
+		if !ok || !keys[k] {
+			q.text = append(q.text, strings.Trim(raw, `"`))
+			continue
+		}
+		v = strings.Trim(v, `"`)
+		if v == "" {
+			return nil, fmt.Errorf("%s: missing value", k)
+		}
+		t := term{raw: raw, key: k, value: strings.ToLower(v), negate: negate}
+		switch k {
+		case "age", "updated":
+			op, dur, err := parseComparison(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			t.op, t.dur = op, dur
+		case "is", "checks", "review":
+			if !contains(allowed[k], t.value) {
+				return nil, fmt.Errorf("%s: unknown value %q (want one of %s)", k, v, strings.Join(allowed[k], ", "))
+			}
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+// Text returns the free-text part of the query.
+func (q *Query) Text() string { return strings.Join(q.text, " ") }
+
+// Empty reports whether the query has no terms and no text.
+func (q *Query) Empty() bool { return len(q.terms) == 0 && len(q.text) == 0 }
+
+// MatchTerms evaluates only the key:value terms, leaving free text to the
+// caller (the picker fuzzy-matches it).
+func (q *Query) MatchTerms(pr gh.RepoPR, env Env) bool {
+	for _, t := range q.terms {
+		if t.match(pr, env) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Failing returns the key:value terms pr doesn't satisfy, as written, so
+// callers can explain a mismatch. Free text is not considered.
+func (q *Query) Failing(pr gh.RepoPR, env Env) []string {
+	var failed []string
+	for _, t := range q.terms {
+		if t.match(pr, env) == t.negate {
+			failed = append(failed, t.raw)
+		}
+	}
+	return failed
+}
+
+// Match evaluates the whole query; free-text words must each appear in the
+// PR number, title, branch or repo (case-insensitive).
+func (q *Query) Match(pr gh.RepoPR, env Env) bool {
+	if !q.MatchTerms(pr, env) {
+		return false
+	}
+	hay := strings.ToLower(fmt.Sprintf("#%d %s %s %s", pr.PR.Number, pr.PR.Title, pr.PR.HeadRefName, pr.Repo))
+	for _, w := range q.text {
+		if !strings.Contains(hay, strings.ToLower(w)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(r gh.RepoPR, env Env) bool {
+	pr := r.PR
+	switch t.key {
+	case "author":
+		return sameLogin(pr.Author.Login, resolveMe(t.value, env))
+	case "label":
+		for _, l := range pr.Labels {
+			if glob(t.value, strings.ToLower(l.Name)) {
+				return true
+			}
+		}
+		return false
+	case "is":
+		switch t.value {
+		case "open", "closed", "merged":
+			return strings.EqualFold(pr.State, t.value)
+		case "draft":
+			return pr.IsDraft
+		case "conflict":
+			return pr.Mergeable == "CONFLICTING"
+		case "mergeable":
+			return pr.Mergeable == "MERGEABLE"
+		case "approved":
+			return pr.ReviewDecision == "APPROVED"
+		case "bot":
+			return IsBot(pr.Author)
+		}
+	case "checks":
+		state := gh.CheckState(pr.StatusCheckRollup)
+		switch t.value {
+		case "passing":
+			return state == gh.ChecksPassing
+		case "failing":
+			return state == gh.ChecksFailing
+		case "pending":
+			return state == gh.ChecksPending
+		case "none":
+			return state == ""
+		}
+	case "review":
+		switch t.value {
+		case "approved":
+			return pr.ReviewDecision == "APPROVED"
+		case "changes":
+			return pr.ReviewDecision == "CHANGES_REQUESTED"
+		case "required":
+			return pr.ReviewDecision == "REVIEW_REQUIRED"
+		case "none":
+			return pr.ReviewDecision == ""
+		}
+	case "review-requested":
+		want := resolveMe(t.value, env)
+		for _, rr := range pr.ReviewRequests {
+			if sameLogin(rr.Login, want) || (rr.Slug != "" && strings.EqualFold(rr.Slug, strings.TrimPrefix(want, "@"))) {
+				return true
+			}
+		}
+		return false
+	case "age":
+		return compareSince(pr.CreatedAt, t, env.Now)
+	case "updated":
+		return compareSince(pr.UpdatedAt, t, env.Now)
+	case "repo":
+		repo := strings.ToLower(r.Repo)
+		if strings.Contains(t.value, "/") {
+			return glob(t.value, repo)
+		}
+		_, name, _ := strings.Cut(repo, "/")
+		return glob(t.value, name)
+	case "head":
+		return glob(t.value, strings.ToLower(pr.HeadRefName))
+	case "base":
+		return glob(t.value, strings.ToLower(pr.BaseRefName))
+	}
+	return false
+}
+
+// IsBot reports whether an author is a bot or GitHub App account.
+func IsBot(a gh.Actor) bool {
+	l := strings.ToLower(a.Login)
+	return a.IsBot || strings.HasPrefix(l, "app/") || strings.HasSuffix(l, "[bot]")
+}
+
+// NormalizeLogin lowercases a login and strips bot decorations so that
+// "app/dependabot", "dependabot[bot]" and "dependabot" compare equal.
+func NormalizeLogin(login string) string {
+	l := strings.ToLower(strings.TrimSpace(login))
+	l = strings.TrimPrefix(l, "@")
+	l = strings.TrimPrefix(l, "app/")
+	return strings.TrimSuffix(l, "[bot]")
+}
+
+func sameLogin(a, b string) bool {
+	return a != "" && NormalizeLogin(a) == NormalizeLogin(b)
+}
+
+func resolveMe(v string, env Env) string {
+	if v == "me" || v == "@me" {
+		return env.Me
+	}
+	return v
+}
+
+func glob(pattern, s string) bool {
+	if ok, err := path.Match(pattern, s); err == nil && ok {
+		return true
+	}
+	return pattern == s
+}
+
+func compareSince(ts string, t term, now time.Time) bool {
+	at, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(at)
+	switch t.op {
+	case ">":
+		return age > t.dur
+	case "<":
+		return age < t.dur
+	case "<=":
+		return age <= t.dur
+	default:
+		return age >= t.dur
+	}
+}
+
+func parseComparison(v string) (string, time.Duration, error) {
+	op := ">="
+	for _, candidate := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(v, candidate) {
+			op = candidate
+			v = v[len(candidate):]
+			break
+		}
+	}
+	d, err := ParseAge(v)
+	return op, d, err
+}
+
+// ParseAge parses a compact duration such as "90m", "12h", "3d", "2w",
+// "6mo" or "1y". Months are 30 days and years 365 days.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 3d, 2w, 12h)", s)
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q: %w", s, err)
+	}
+	day := 24 * time.Hour
+	units := map[string]time.Duration{
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  day,
+		"w":  7 * day,
+		"mo": 30 * day,
+		"y":  365 * day,
+	}
+	unit, ok := units[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid age unit in %q (use m, h, d, w, mo or y)", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// Tokenize splits on whitespace, keeping double-quoted sections together.
+func Tokenize(s string) []string {
+	var toks []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if cur.Len() > 0 {
+				toks = append(toks, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		toks = append(toks, cur.String())
+	}
+	return toks
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
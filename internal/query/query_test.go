@@ -0,0 +1,123 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func fixture() gh.RepoPR {
+	pr := gh.PR{
+		Number:         42,
+		Title:          "Bump lodash from 4.17.20 to 4.17.21",
+		HeadRefName:    "dependabot/npm/lodash-4.17.21",
+		BaseRefName:    "main",
+		State:          "OPEN",
+		CreatedAt:      now.Add(-5 * 24 * time.Hour).Format(time.RFC3339),
+		UpdatedAt:      now.Add(-2 * time.Hour).Format(time.RFC3339),
+		Mergeable:      "MERGEABLE",
+		ReviewDecision: "APPROVED",
+		Labels:         []gh.Label{{Name: "dependencies"}, {Name: "javascript"}},
+		ReviewRequests: []gh.ReviewRequest{{TypeName: "User", Login: "alice"}},
+		StatusCheckRollup: []gh.Check{
+			{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SUCCESS"},
+		},
+	}
+	pr.Author = gh.Actor{Login: "app/dependabot", IsBot: true}
+	return gh.RepoPR{Repo: "acme/web", PR: pr}
+}
+
+func TestMatch(t *testing.T) {
+	env := Env{Me: "alice", Now: now}
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"author:dependabot", true},
+		{"author:dependabot[bot]", true},
+		{"author:me", false},
+		{"-author:me", true},
+		{"review-requested:me", true},
+		{"label:dependencies", true},
+		{"label:dep*", true},
+		{"-label:javascript", false},
+		{"is:open is:mergeable is:bot -is:draft", true},
+		{"is:conflict", false},
+		{"checks:passing review:approved", true},
+		{"checks:failing", false},
+		{"age:>3d", true},
+		{"age:>1w", false},
+		{"age:<1w", true},
+		{"updated:<1d", true},
+		{"repo:web", true},
+		{"repo:acme/*", true},
+		{"repo:other", false},
+		{"head:dependabot/*", false},
+		{"base:main", true},
+		{"lodash", true},
+		{"lodash react", false},
+		{"label:\"dependencies\" fix:", false},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.query)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", c.query, err)
+		}
+		if got := q.Match(fixture(), env); got != c.want {
+			t.Errorf("%q: got %v, want %v", c.query, got, c.want)
+		}
+	}
+}
+
+func TestMatchTermsIgnoresText(t *testing.T) {
+	q, err := Parse("is:open nothing-like-this")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.MatchTerms(fixture(), Env{Now: now}) {
+		t.Fatalf("free text should not affect MatchTerms")
+	}
+	if q.Text() != "nothing-like-this" {
+		t.Fatalf("unexpected text %q", q.Text())
+	}
+}
+
+func TestFailing(t *testing.T) {
+	q, err := Parse(`is:open -is:draft label:"needs review" checks:failing author:someone-else`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(q.Failing(fixture(), Env{Now: now}), " ")
+	if want := `label:"needs review" checks:failing author:someone-else`; got != want {
+		t.Fatalf("Failing = %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"is:flying", "checks:green", "age:>soon", "age:3x", "label:"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"90m": 90 * time.Minute,
+		"12h": 12 * time.Hour,
+		"3d":  72 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"1mo": 30 * 24 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParseAge(in)
+		if err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+}
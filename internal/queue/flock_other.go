@@ -0,0 +1,8 @@
+//go:build !unix
+
+package queue
+
+import "os"
+
+// lockFile is a no-op where flock isn't available.
+func lockFile(*os.File) error { return nil }
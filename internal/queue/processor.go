@@ -0,0 +1,260 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+// Backend is the subset of GitHub operations the processor needs.
+type Backend interface {
+	Details(ctx context.Context, repo string, number int) (*gh.PRDetails, error)
+	UpdateBranch(ctx context.Context, repo string, number int) error
+	Merge(ctx context.Context, repo string, number int, strategy string, deleteBranch bool) error
+}
+
+// GHBackend runs the processor against GitHub via the gh CLI.
+type GHBackend struct{}
+
+func (GHBackend) Details(ctx context.Context, repo string, number int) (*gh.PRDetails, error) {
+	return gh.GetPRDetails(ctx, repo, number)
+}
+
+func (GHBackend) UpdateBranch(ctx context.Context, repo string, number int) error {
+	return gh.UpdateBranch(ctx, repo, number, false)
+}
+
+func (GHBackend) Merge(ctx context.Context, repo string, number int, strategy string, deleteBranch bool) error {
+	return gh.MergePR(ctx, repo, number, strategy, deleteBranch)
+}
+
+// Event reports progress on one queue entry.
+type Event struct {
+	Number int
+	Status Status
+	Reason string
+}
+
+type Processor struct {
+	Dir          string
+	Repo         string
+	Backend      Backend
+	Strategy     string
+	DeleteBranch bool
+	// PollInterval is how often checks are polled while waiting.
+	PollInterval time.Duration
+	// CheckTimeout bounds how long one PR may wait for checks.
+	CheckTimeout time.Duration
+	// OnEvent, if set, is called for every status change.
+	OnEvent func(Event)
+
+	now func() time.Time
+}
+
+// maxUpdates limits how often one PR is brought up to date while waiting,
+// in case the base keeps moving.
+const maxUpdates = 3
+
+// errRejected marks a PR that must leave the queue without being merged.
+var errRejected = errors.New("rejected")
+
+type rejection struct{ reason string }
+
+func (r rejection) Error() string { return r.reason }
+func (r rejection) Unwrap() error { return errRejected }
+
+func reject(format string, args ...any) error {
+	return rejection{reason: fmt.Sprintf(format, args...)}
+}
+
+// Run processes the queue until it is empty or ctx is cancelled. PRs that
+// can't be merged are removed with a reason; infrastructure errors (e.g.
+// gh failing to reach GitHub) stop the run and leave the PR queued.
+func (p *Processor) Run(ctx context.Context) error {
+	for {
+		q, err := Load(p.Dir, p.Repo)
+		if err != nil {
+			return err
+		}
+		head, ok := q.Head()
+		if !ok {
+			return nil
+		}
+		if err := p.process(ctx, head.Number); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Processor) process(ctx context.Context, number int) error {
+	err := p.advance(ctx, number)
+	var r rejection
+	switch {
+	case err == nil:
+		return p.finish(number, Merged, "")
+	case errors.As(err, &r):
+		return p.finish(number, Failed, r.reason)
+	default:
+		// Leave the entry at the head so the next run retries it.
+		if serr := p.update(number, Queued, err.Error()); serr != nil {
+			return serr
+		}
+		return err
+	}
+}
+
+func (p *Processor) advance(ctx context.Context, number int) error {
+	deadline := p.clock().Add(p.CheckTimeout)
+	updates := 0
+	for {
+		d, err := p.Backend.Details(ctx, p.Repo, number)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.State == "MERGED":
+			return nil
+		case d.State != "OPEN":
+			return reject("PR is %s", strings.ToLower(d.State))
+		case d.IsDraft:
+			return reject("PR is a draft")
+		case d.Mergeable == "CONFLICTING" || d.MergeStateStatus == "DIRTY":
+			return reject("merge conflicts with %s", d.BaseRefName)
+		case d.ReviewDecision == "CHANGES_REQUESTED":
+			return reject("changes requested")
+		}
+
+		if d.MergeStateStatus == "BEHIND" {
+			if updates >= maxUpdates {
+				return reject("base branch keeps moving; gave up after %d updates", updates)
+			}
+			if err := p.update(number, Updating, ""); err != nil {
+				return err
+			}
+			if err := p.Backend.UpdateBranch(ctx, p.Repo, number); err != nil {
+				if transient(ctx, err) {
+					return err
+				}
+				return reject("update branch failed: %v", err)
+			}
+			updates++
+			if err := p.sleep(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch gh.CheckState(d.StatusCheckRollup) {
+		case gh.ChecksFailing:
+			return reject("checks failed: %s", strings.Join(failingChecks(d.StatusCheckRollup), ", "))
+		case gh.ChecksPending:
+			if p.clock().After(deadline) {
+				return reject("checks did not finish within %s", p.CheckTimeout)
+			}
+			if err := p.update(number, Waiting, "waiting for checks"); err != nil {
+				return err
+			}
+			if err := p.sleep(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if d.ReviewDecision == "REVIEW_REQUIRED" {
+			return reject("approval required")
+		}
+		if d.Mergeable == "UNKNOWN" {
+			if p.clock().After(deadline) {
+				return reject("mergeability unknown after %s", p.CheckTimeout)
+			}
+			if err := p.sleep(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.update(number, Merging, ""); err != nil {
+			return err
+		}
+		if err := p.Backend.Merge(ctx, p.Repo, number, p.Strategy, p.DeleteBranch); err != nil {
+			if transient(ctx, err) {
+				return err
+			}
+			return reject("merge failed: %v", err)
+		}
+		return nil
+	}
+}
+
+// transient reports whether err says nothing about the PR itself, so it
+// stays queued for the next run.
+func transient(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, gh.ErrNetwork) || errors.Is(err, gh.ErrRateLimited)
+}
+
+func failingChecks(checks []gh.Check) []string {
+	var names []string
+	for _, c := range checks {
+		if gh.CheckState([]gh.Check{c}) != gh.ChecksFailing {
+			continue
+		}
+		name := c.Name
+		if name == "" {
+			name = c.Context
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// update and finish go through Modify so entries added by `shippr queue
+// add` while the runner is busy are not lost.
+func (p *Processor) update(number int, s Status, reason string) error {
+	err := Modify(p.Dir, p.Repo, func(q *Queue) error {
+		q.setStatus(number, s, reason, p.clock())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.emit(Event{Number: number, Status: s, Reason: reason})
+	return nil
+}
+
+func (p *Processor) finish(number int, s Status, reason string) error {
+	err := Modify(p.Dir, p.Repo, func(q *Queue) error {
+		q.finish(number, s, reason, p.clock())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.emit(Event{Number: number, Status: s, Reason: reason})
+	return nil
+}
+
+func (p *Processor) emit(e Event) {
+	if p.OnEvent != nil {
+		p.OnEvent(e)
+	}
+}
+
+func (p *Processor) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+func (p *Processor) sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.PollInterval):
+		return nil
+	}
+}
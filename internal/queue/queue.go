@@ -0,0 +1,198 @@
+// Package queue implements a local merge train for repositories without
+// GitHub's merge queue: PRs are processed one at a time (update branch, wait
+// for checks, merge) and the queue is persisted on disk between runs.
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Status string
+
+const (
+	Queued   Status = "queued"
+	Updating Status = "updating"
+	Waiting  Status = "waiting"
+	Merging  Status = "merging"
+	Merged   Status = "merged"
+	Failed   Status = "failed"
+)
+
+// historyLimit caps how many finished entries are kept for reporting.
+const historyLimit = 50
+
+type Entry struct {
+	Number     int       `json:"number"`
+	Title      string    `json:"title,omitempty"`
+	Status     Status    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Queue is the on-disk state for one repository.
+type Queue struct {
+	Repo    string  `json:"repo"`
+	Entries []Entry `json:"entries"`
+	History []Entry `json:"history,omitempty"`
+
+	path string
+}
+
+// Path returns the state file for repo inside dir.
+func Path(dir, repo string) string {
+	return filepath.Join(dir, "queues", strings.ReplaceAll(repo, "/", "__")+".json")
+}
+
+// Load reads the queue for repo from dir; a missing file is an empty queue.
+func Load(dir, repo string) (*Queue, error) {
+	q := &Queue{Repo: repo, path: Path(dir, repo)}
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read queue: %w", err)
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("parse queue %s: %w", q.path, err)
+	}
+	return q, nil
+}
+
+// Modify loads the queue for repo, applies fn and saves the result while
+// holding an advisory lock, so a runner and `shippr queue add` editing the
+// queue at the same time don't drop each other's changes. An error from fn
+// leaves the queue as it was.
+func Modify(dir, repo string, fn func(*Queue) error) error {
+	path := Path(dir, repo)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".mu", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("lock queue: %w", err)
+	}
+	q, err := Load(dir, repo)
+	if err != nil {
+		return err
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	return q.Save()
+}
+
+// Save writes the queue atomically.
+func (q *Queue) Save() error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// Add enqueues a PR at the back of the queue.
+func (q *Queue) Add(number int, title string, now time.Time) error {
+	if q.Position(number) > 0 {
+		return fmt.Errorf("#%d is already queued", number)
+	}
+	q.Entries = append(q.Entries, Entry{Number: number, Title: title, Status: Queued, EnqueuedAt: now, UpdatedAt: now})
+	return nil
+}
+
+// Remove drops a PR from the queue, reporting whether it was there.
+func (q *Queue) Remove(number int) bool {
+	for i, e := range q.Entries {
+		if e.Number == number {
+			q.Entries = append(q.Entries[:i], q.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Position is the 1-based place of number in the queue, or 0.
+func (q *Queue) Position(number int) int {
+	for i, e := range q.Entries {
+		if e.Number == number {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Head returns the entry to process next.
+func (q *Queue) Head() (Entry, bool) {
+	if len(q.Entries) == 0 {
+		return Entry{}, false
+	}
+	return q.Entries[0], true
+}
+
+func (q *Queue) setStatus(number int, s Status, reason string, now time.Time) {
+	for i := range q.Entries {
+		if q.Entries[i].Number == number {
+			q.Entries[i].Status = s
+			q.Entries[i].Reason = reason
+			q.Entries[i].UpdatedAt = now
+			return
+		}
+	}
+}
+
+// finish moves an entry from the queue into history.
+func (q *Queue) finish(number int, s Status, reason string, now time.Time) {
+	for i, e := range q.Entries {
+		if e.Number != number {
+			continue
+		}
+		e.Status, e.Reason, e.UpdatedAt = s, reason, now
+		q.Entries = append(q.Entries[:i], q.Entries[i+1:]...)
+		q.History = append([]Entry{e}, q.History...)
+		if len(q.History) > historyLimit {
+			q.History = q.History[:historyLimit]
+		}
+		return
+	}
+}
+
+// Lock marks the queue as being processed so two runners can't race. The
+// returned func releases it.
+func (q *Queue) Lock() (func(), error) {
+	lock := q.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		pid, _ := os.ReadFile(lock)
+		return nil, fmt.Errorf("queue for %s is already being processed (pid %s); remove %s if that process is gone",
+			q.Repo, strings.TrimSpace(string(pid)), lock)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+	_ = f.Close()
+	return func() { _ = os.Remove(lock) }, nil
+}
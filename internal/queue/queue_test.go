@@ -0,0 +1,238 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+// fakeBackend serves a scripted sequence of PR states per number; the last
+// state repeats once the script runs out.
+type fakeBackend struct {
+	states   map[int][]gh.PRDetails
+	calls    map[int]int
+	updated  []int
+	merged   []int
+	mergeErr error
+}
+
+func (f *fakeBackend) Details(_ context.Context, _ string, number int) (*gh.PRDetails, error) {
+	seq := f.states[number]
+	i := min(f.calls[number], len(seq)-1)
+	f.calls[number]++
+	d := seq[i]
+	d.Number = number
+	return &d, nil
+}
+
+func (f *fakeBackend) UpdateBranch(_ context.Context, _ string, number int) error {
+	f.updated = append(f.updated, number)
+	return nil
+}
+
+func (f *fakeBackend) Merge(_ context.Context, _ string, number int, _ string, _ bool) error {
+	if f.mergeErr != nil {
+		return f.mergeErr
+	}
+	f.merged = append(f.merged, number)
+	return nil
+}
+
+var (
+	green   = []gh.Check{{TypeName: "CheckRun", Name: "build", Status: "COMPLETED", Conclusion: "SUCCESS"}}
+	red     = []gh.Check{{TypeName: "CheckRun", Name: "build", Status: "COMPLETED", Conclusion: "FAILURE"}}
+	running = []gh.Check{{TypeName: "CheckRun", Name: "build", Status: "IN_PROGRESS"}}
+)
+
+func ready() gh.PRDetails {
+	return gh.PRDetails{State: "OPEN", Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN", StatusCheckRollup: green}
+}
+
+func newProcessor(t *testing.T, b Backend, numbers ...int) *Processor {
+	t.Helper()
+	dir := t.TempDir()
+	q, err := Load(dir, "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range numbers {
+		if err := q.Add(n, "", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return &Processor{Dir: dir, Repo: "acme/web", Backend: b, Strategy: "--squash", CheckTimeout: time.Hour}
+}
+
+func TestRunMergesInOrderAndRejectsFailures(t *testing.T) {
+	behind := ready()
+	behind.MergeStateStatus = "BEHIND"
+	pending := ready()
+	pending.StatusCheckRollup = running
+	failing := ready()
+	failing.StatusCheckRollup = red
+
+	b := &fakeBackend{
+		calls: map[int]int{},
+		states: map[int][]gh.PRDetails{
+			1: {behind, pending, ready()},
+			2: {failing},
+			3: {ready()},
+		},
+	}
+	p := newProcessor(t, b, 1, 2, 3)
+	var events []Event
+	p.OnEvent = func(e Event) { events = append(events, e) }
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(b.updated) != 1 || b.updated[0] != 1 {
+		t.Fatalf("expected #1 to be updated once, got %v", b.updated)
+	}
+	if len(b.merged) != 2 || b.merged[0] != 1 || b.merged[1] != 3 {
+		t.Fatalf("expected #1 and #3 merged in order, got %v", b.merged)
+	}
+
+	q, _ := Load(p.Dir, p.Repo)
+	if len(q.Entries) != 0 {
+		t.Fatalf("queue should be empty, got %v", q.Entries)
+	}
+	if len(q.History) != 3 || q.History[1].Number != 2 || q.History[1].Status != Failed {
+		t.Fatalf("expected #2 failed in history, got %+v", q.History)
+	}
+	if q.History[1].Reason != "checks failed: build" {
+		t.Fatalf("unexpected reason %q", q.History[1].Reason)
+	}
+	last := events[len(events)-1]
+	if last.Number != 3 || last.Status != Merged {
+		t.Fatalf("unexpected last event %+v", last)
+	}
+}
+
+func TestMergeFailureIsReported(t *testing.T) {
+	b := &fakeBackend{
+		calls:    map[int]int{},
+		states:   map[int][]gh.PRDetails{7: {ready()}},
+		mergeErr: errors.New("required status check is expected"),
+	}
+	p := newProcessor(t, b, 7)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	q, _ := Load(p.Dir, p.Repo)
+	if len(q.History) != 1 || q.History[0].Status != Failed {
+		t.Fatalf("expected failed entry, got %+v", q.History)
+	}
+}
+
+func TestCancelLeavesEntryQueued(t *testing.T) {
+	pending := ready()
+	pending.StatusCheckRollup = running
+	b := &fakeBackend{calls: map[int]int{}, states: map[int][]gh.PRDetails{5: {pending}}}
+	p := newProcessor(t, b, 5)
+	p.PollInterval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	p.OnEvent = func(e Event) {
+		if e.Status == Waiting {
+			cancel()
+		}
+	}
+	if err := p.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	q, _ := Load(p.Dir, p.Repo)
+	if q.Position(5) != 1 || q.Entries[0].Status != Queued {
+		t.Fatalf("cancelled PR should stay queued, got %+v", q.Entries)
+	}
+}
+
+func TestAddRemovePersist(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := Load(dir, "acme/web")
+	_ = q.Add(1, "one", time.Now())
+	if err := q.Add(1, "one", time.Now()); err == nil {
+		t.Fatalf("duplicate add should fail")
+	}
+	_ = q.Add(2, "two", time.Now())
+	if err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+	q, _ = Load(dir, "acme/web")
+	if q.Position(2) != 2 {
+		t.Fatalf("expected #2 at position 2")
+	}
+	if !q.Remove(1) || q.Position(2) != 1 {
+		t.Fatalf("remove should shift positions")
+	}
+	unlock, err := q.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Lock(); err == nil {
+		t.Fatalf("second lock should fail")
+	}
+	unlock()
+}
+
+func TestModifyKeepsConcurrentEdits(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for n := 1; n <= 20; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Modify(dir, "acme/web", func(q *Queue) error { return q.Add(n, "", time.Now()) }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	q, err := Load(dir, "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Entries) != 20 {
+		t.Fatalf("queue has %d entries, want all 20 adds", len(q.Entries))
+	}
+}
+
+func TestNetworkErrorLeavesEntryQueued(t *testing.T) {
+	b := &fakeBackend{
+		calls:    map[int]int{},
+		states:   map[int][]gh.PRDetails{7: {ready()}},
+		mergeErr: fmt.Errorf("gh pr merge: %w", gh.ErrNetwork),
+	}
+	p := newProcessor(t, b, 7)
+	if err := p.Run(context.Background()); !errors.Is(err, gh.ErrNetwork) {
+		t.Fatalf("run = %v, want the network error", err)
+	}
+	q, _ := Load(p.Dir, p.Repo)
+	if q.Position(7) != 1 || q.Entries[0].Status != Queued || len(q.History) != 0 {
+		t.Fatalf("PR should stay queued after a network error, got %+v / %+v", q.Entries, q.History)
+	}
+}
+
+func TestUnknownMergeabilityTimesOut(t *testing.T) {
+	unknown := ready()
+	unknown.Mergeable = "UNKNOWN"
+	b := &fakeBackend{calls: map[int]int{}, states: map[int][]gh.PRDetails{4: {unknown}}}
+	p := newProcessor(t, b, 4)
+	now := time.Now()
+	p.now = func() time.Time { now = now.Add(time.Minute); return now }
+	p.CheckTimeout = 5 * time.Minute
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	q, _ := Load(p.Dir, p.Repo)
+	if len(q.History) != 1 || q.History[0].Status != Failed || q.History[0].Reason != "mergeability unknown after 5m0s" {
+		t.Fatalf("expected a timed-out rejection, got %+v", q.History)
+	}
+}
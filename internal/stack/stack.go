@@ -0,0 +1,129 @@
+// Package stack detects stacked PRs: chains where one PR's base branch is
+// another open PR's head branch.
+package stack
+
+import "git-shippr/internal/gh"
+
+type Node struct {
+	PR       gh.PR
+	Parent   *Node
+	Children []*Node
+	Depth    int
+}
+
+// Build arranges prs into a forest. Roots are PRs whose base is not the
+// head of another PR in the set (usually the trunk). Input order is kept
+// among siblings.
+func Build(prs []gh.PR) []*Node {
+	nodes := make([]*Node, len(prs))
+	byHead := make(map[string]*Node, len(prs))
+	for i := range prs {
+		nodes[i] = &Node{PR: prs[i]}
+		byHead[prs[i].HeadRefName] = nodes[i]
+	}
+	var roots []*Node
+	for _, n := range nodes {
+		parent, ok := byHead[n.PR.BaseRefName]
+		if !ok || parent == n || isAncestor(n, parent) {
+			roots = append(roots, n)
+			continue
+		}
+		n.Parent = parent
+		parent.Children = append(parent.Children, n)
+	}
+	for _, r := range roots {
+		setDepth(r, 0)
+	}
+	return roots
+}
+
+// isAncestor guards against base/head cycles.
+func isAncestor(n, candidate *Node) bool {
+	for p := candidate; p != nil; p = p.Parent {
+		if p == n {
+			return true
+		}
+	}
+	return false
+}
+
+func setDepth(n *Node, d int) {
+	n.Depth = d
+	for _, c := range n.Children {
+		setDepth(c, d+1)
+	}
+}
+
+// Flatten returns the nodes in depth-first order, parents before children.
+func Flatten(roots []*Node) []*Node {
+	var out []*Node
+	var walk func(*Node)
+	walk = func(n *Node) {
+		out = append(out, n)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, r := range roots {
+		walk(r)
+	}
+	return out
+}
+
+// HasStacks reports whether any PR sits on top of another.
+func HasStacks(roots []*Node) bool {
+	for _, r := range roots {
+		if len(r.Children) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Chain returns the PRs from the bottom of the stack up to and including
+// number, in merge order. It returns nil if number is not in the forest.
+func Chain(roots []*Node, number int) []*Node {
+	for _, n := range Flatten(roots) {
+		if n.PR.Number != number {
+			continue
+		}
+		var chain []*Node
+		for p := n; p != nil; p = p.Parent {
+			chain = append([]*Node{p}, chain...)
+		}
+		return chain
+	}
+	return nil
+}
+
+// Trunk is the branch the bottom of the chain targets.
+func Trunk(chain []*Node) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[0].PR.BaseRefName
+}
+
+// Prefix draws the tree guide for n, e.g. "│  └─ ".
+func Prefix(n *Node) string {
+	if n.Parent == nil {
+		return ""
+	}
+	prefix := "└─ "
+	if !isLast(n) {
+		prefix = "├─ "
+	}
+	for p := n.Parent; p.Parent != nil; p = p.Parent {
+		if isLast(p) {
+			prefix = "   " + prefix
+		} else {
+			prefix = "│  " + prefix
+		}
+	}
+	return prefix
+}
+
+func isLast(n *Node) bool {
+	siblings := n.Parent.Children
+	return siblings[len(siblings)-1] == n
+}
@@ -0,0 +1,80 @@
+package stack
+
+import (
+	"testing"
+
+	"git-shippr/internal/gh"
+)
+
+func pr(number int, head, base string) gh.PR {
+	return gh.PR{Number: number, HeadRefName: head, BaseRefName: base}
+}
+
+func numbers(nodes []*Node) []int {
+	out := make([]int, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.PR.Number
+	}
+	return out
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildAndChain(t *testing.T) {
+	prs := []gh.PR{
+		pr(3, "feat-c", "feat-b"),
+		pr(1, "feat-a", "main"),
+		pr(9, "other", "main"),
+		pr(2, "feat-b", "feat-a"),
+		pr(4, "feat-b2", "feat-a"),
+	}
+	roots := Build(prs)
+	if !HasStacks(roots) {
+		t.Fatalf("expected stacks")
+	}
+	if got := numbers(Flatten(roots)); !equal(got, []int{1, 2, 3, 4, 9}) {
+		t.Fatalf("unexpected flatten order %v", got)
+	}
+	chain := Chain(roots, 3)
+	if got := numbers(chain); !equal(got, []int{1, 2, 3}) {
+		t.Fatalf("unexpected chain %v", got)
+	}
+	if Trunk(chain) != "main" {
+		t.Fatalf("unexpected trunk %q", Trunk(chain))
+	}
+	if Chain(roots, 42) != nil {
+		t.Fatalf("unknown PR should have no chain")
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	roots := Build([]gh.PR{
+		pr(1, "a", "main"),
+		pr(2, "b", "a"),
+		pr(3, "c", "b"),
+		pr(4, "d", "a"),
+	})
+	want := map[int]string{1: "", 2: "├─ ", 3: "│  └─ ", 4: "└─ "}
+	for _, n := range Flatten(roots) {
+		if got := Prefix(n); got != want[n.PR.Number] {
+			t.Errorf("#%d: prefix %q, want %q", n.PR.Number, got, want[n.PR.Number])
+		}
+	}
+}
+
+func TestCycleDoesNotLoop(t *testing.T) {
+	roots := Build([]gh.PR{pr(1, "a", "b"), pr(2, "b", "a")})
+	if len(Flatten(roots)) != 2 {
+		t.Fatalf("cycle should still yield both PRs")
+	}
+}
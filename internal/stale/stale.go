@@ -0,0 +1,139 @@
+// Package stale finds abandoned PRs and decides what to do about them: warn
+// once a PR has had no activity for a while, then close it if the warning
+// goes unanswered for a grace period.
+package stale
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+// DefaultLabel marks PRs that have been warned.
+const DefaultLabel = "stale"
+
+// Policy says when a PR counts as stale and what happens next.
+type Policy struct {
+	// OlderThan is how long a PR must go without activity to be stale.
+	OlderThan time.Duration
+	// Label marks warned PRs. Warning a PR is itself activity, so the label
+	// is how a later run tells "warned and ignored" from "just went quiet".
+	Label string
+	// Grace is how long a warned PR may stay inactive before it is closed;
+	// 0 never closes.
+	Grace time.Duration
+}
+
+// Action is the next step for a stale PR.
+type Action int
+
+const (
+	// Warn: the PR went stale and hasn't been warned yet.
+	Warn Action = iota
+	// Wait: warned, still within the grace period.
+	Wait
+	// Close: warned and inactive for the whole grace period.
+	Close
+	// Revive: labelled, but active since, so the label comes off.
+	Revive
+)
+
+func (a Action) String() string {
+	return [...]string{"warn", "wait", "close", "revive"}[a]
+}
+
+// LabeledAt reports when the stale label was last added to a PR; ok is
+// false when that can't be told.
+type LabeledAt func(gh.RepoPR) (t time.Time, ok bool)
+
+// activitySlack absorbs the update that labelling itself causes.
+const activitySlack = time.Minute
+
+// PR is a stale PR and what to do about it.
+type PR struct {
+	gh.RepoPR
+	// Idle is the time since the PR was last updated.
+	Idle   time.Duration
+	Action Action
+}
+
+// Find returns the open PRs among prs that are stale under p, oldest first.
+// PRs with an unparseable UpdatedAt are skipped. Labelled PRs are judged
+// by when the label was added, so a PR that was picked up again after its
+// warning, or labelled by hand, is never closed without a fresh warning.
+func Find(prs []gh.RepoPR, p Policy, now time.Time, labeledAt LabeledAt) []PR {
+	var out []PR
+	for _, r := range prs {
+		if r.PR.State != "" && r.PR.State != "OPEN" {
+			continue
+		}
+		updated, err := time.Parse(time.RFC3339, r.PR.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		idle := now.Sub(updated)
+		switch {
+		case p.Label != "" && hasLabel(r.PR, p.Label):
+			var warned time.Time
+			ok := false
+			if labeledAt != nil {
+				warned, ok = labeledAt(r)
+			}
+			a := Wait
+			switch {
+			case !ok:
+				// Without the warning's time the grace period can't be
+				// timed, so the PR is left alone.
+			case updated.Sub(warned) > activitySlack:
+				a = Revive
+			case p.Grace > 0 && now.Sub(warned) >= p.Grace:
+				a = Close
+			}
+			out = append(out, PR{RepoPR: r, Idle: idle, Action: a})
+		case idle >= p.OlderThan:
+			out = append(out, PR{RepoPR: r, Idle: idle, Action: Warn})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b PR) int { return cmp.Compare(b.Idle, a.Idle) })
+	return out
+}
+
+func hasLabel(pr gh.PR, name string) bool {
+	for _, l := range pr.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Group is one author's stale PRs in one repository.
+type Group struct {
+	Author string
+	Repo   string
+	PRs    []PR
+}
+
+// ByAuthor groups prs by author, then repository, both alphabetically.
+// PRs keep their order within a group.
+func ByAuthor(prs []PR) []Group {
+	var groups []Group
+	index := map[[2]string]int{}
+	for _, p := range prs {
+		key := [2]string{p.PR.Author.Login, p.Repo}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Author: key[0], Repo: key[1]})
+		}
+		groups[i].PRs = append(groups[i].PRs, p)
+	}
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(strings.ToLower(a.Author), strings.ToLower(b.Author)), cmp.Compare(a.Repo, b.Repo))
+	})
+	return groups
+}
@@ -0,0 +1,107 @@
+package stale
+
+import (
+	"testing"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func pr(repo string, number int, author string, idleDays int, labels ...string) gh.RepoPR {
+	p := gh.PR{
+		Number:    number,
+		State:     "OPEN",
+		Author:    gh.Actor{Login: author},
+		UpdatedAt: now.Add(-time.Duration(idleDays) * 24 * time.Hour).Format(time.RFC3339),
+	}
+	for _, l := range labels {
+		p.Labels = append(p.Labels, gh.Label{Name: l})
+	}
+	return gh.RepoPR{Repo: repo, PR: p}
+}
+
+func TestFind(t *testing.T) {
+	policy := Policy{OlderThan: 30 * 24 * time.Hour, Label: DefaultLabel, Grace: 7 * 24 * time.Hour}
+	prs := []gh.RepoPR{
+		pr("o/a", 1, "ann", 5),
+		pr("o/a", 2, "ann", 45),
+		pr("o/a", 3, "bob", 3, "stale"),
+		pr("o/b", 4, "bob", 10, "Stale"),
+		pr("o/b", 5, "cat", 90),
+	}
+	closed := pr("o/b", 6, "cat", 400)
+	closed.PR.State = "CLOSED"
+	prs = append(prs, closed)
+
+	// #4 was labelled when it was last updated; #3 was labelled 20 days
+	// ago and has seen activity since.
+	got := Find(prs, policy, now, func(r gh.RepoPR) (time.Time, bool) {
+		if r.PR.Number == 3 {
+			return now.Add(-20 * 24 * time.Hour), true
+		}
+		t, _ := time.Parse(time.RFC3339, r.PR.UpdatedAt)
+		return t, true
+	})
+	want := []struct {
+		number int
+		action Action
+	}{{5, Warn}, {2, Warn}, {4, Close}, {3, Revive}}
+	if len(got) != len(want) {
+		t.Fatalf("Find = %d PRs, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].PR.Number != w.number || got[i].Action != w.action {
+			t.Errorf("Find[%d] = #%d %s, want #%d %s", i, got[i].PR.Number, got[i].Action, w.number, w.action)
+		}
+	}
+}
+
+func TestFindWithoutGraceNeverCloses(t *testing.T) {
+	labelled := func(r gh.RepoPR) (time.Time, bool) { return now.Add(-400 * 24 * time.Hour), true }
+	got := Find([]gh.RepoPR{pr("o/a", 1, "ann", 400, "stale")}, Policy{OlderThan: time.Hour, Label: "stale"}, now, labelled)
+	if len(got) != 1 || got[0].Action != Wait {
+		t.Fatalf("Find = %+v, want a single waiting PR", got)
+	}
+}
+
+func TestFindWithoutLabelTimeNeverCloses(t *testing.T) {
+	policy := Policy{OlderThan: time.Hour, Label: "stale", Grace: time.Hour}
+	got := Find([]gh.RepoPR{pr("o/a", 1, "ann", 400, "stale")}, policy, now, nil)
+	if len(got) != 1 || got[0].Action != Wait {
+		t.Fatalf("Find = %+v, want a single waiting PR", got)
+	}
+}
+
+func TestByAuthor(t *testing.T) {
+	prs := Find([]gh.RepoPR{
+		pr("o/b", 1, "bob", 40),
+		pr("o/a", 2, "ann", 50),
+		pr("o/b", 3, "ann", 60),
+		pr("o/a", 4, "ann", 70),
+	}, Policy{OlderThan: 30 * 24 * time.Hour}, now, nil)
+	groups := ByAuthor(prs)
+	want := []struct {
+		author, repo string
+		numbers      []int
+	}{
+		{"ann", "o/a", []int{4, 2}},
+		{"ann", "o/b", []int{3}},
+		{"bob", "o/b", []int{1}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("ByAuthor = %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Author != w.author || g.Repo != w.repo || len(g.PRs) != len(w.numbers) {
+			t.Fatalf("group %d = %s %s (%d PRs), want %s %s", i, g.Author, g.Repo, len(g.PRs), w.author, w.repo)
+		}
+		for j, n := range w.numbers {
+			if g.PRs[j].PR.Number != n {
+				t.Errorf("group %d PR %d = #%d, want #%d", i, j, g.PRs[j].PR.Number, n)
+			}
+		}
+	}
+}
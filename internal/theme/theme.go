@@ -0,0 +1,146 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Palette holds the colors used across the TUI and CLI output. Colors are
+// hex strings or ANSI color numbers; an empty color renders without color.
+type Palette struct {
+	Base        string `json:"base,omitempty"`
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+	Accent      string `json:"accent"`
+	Text        string `json:"text"`
+	HighlightFg string `json:"highlight_fg"`
+	HighlightBg string `json:"highlight_bg"`
+	Error       string `json:"error"`
+}
+
+const (
+	Auto       = "auto"
+	Dark       = "dark"
+	Light      = "light"
+	Contrast   = "high-contrast"
+	Monochrome = "monochrome"
+)
+
+var builtin = map[string]Palette{
+	Dark: {
+		Primary:     "#C471ED",
+		Secondary:   "#DA70D6",
+		Accent:      "#DDA0DD",
+		Text:        "#E6E6FA",
+		HighlightFg: "#8B008B",
+		HighlightBg: "#E6E6FA",
+		Error:       "#FF1493",
+	},
+	Light: {
+		Primary:     "#6A1B9A",
+		Secondary:   "#2E7D32",
+		Accent:      "#8E24AA",
+		Text:        "#303030",
+		HighlightFg: "#FFFFFF",
+		HighlightBg: "#6A1B9A",
+		Error:       "#C62828",
+	},
+	Contrast: {
+		Primary:     "#FFFF00",
+		Secondary:   "#00FF00",
+		Accent:      "#00FFFF",
+		Text:        "#FFFFFF",
+		HighlightFg: "#000000",
+		HighlightBg: "#FFFF00",
+		Error:       "#FF5555",
+	},
+	Monochrome: {},
+}
+
+// Builtin returns the palette of a built-in theme.
+func Builtin(name string) (Palette, bool) {
+	p, ok := builtin[name]
+	return p, ok
+}
+
+// Names lists the built-in theme names plus any custom ones, sorted.
+func Names(custom map[string]Palette) []string {
+	names := []string{Auto}
+	for n := range builtin {
+		names = append(names, n)
+	}
+	for n := range custom {
+		if _, ok := builtin[n]; !ok && n != Auto {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Resolve picks the palette for name. Custom themes take precedence over
+// built-ins and inherit unset colors from their base, a built-in: dark by
+// default, or the built-in they are named after, which they tweak.
+// "auto" and "" choose dark or light from the terminal background.
+func Resolve(name string, custom map[string]Palette, darkBackground bool) (Palette, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == Auto {
+		if darkBackground {
+			return builtin[Dark], nil
+		}
+		return builtin[Light], nil
+	}
+	if p, ok := lookup(custom, name); ok {
+		baseName := strings.ToLower(p.Base)
+		if _, ok := builtin[name]; ok && baseName == "" {
+			baseName = name
+		} else if baseName == "" {
+			baseName = Dark
+		}
+		base, ok := builtin[baseName]
+		if !ok {
+			return Palette{}, fmt.Errorf("theme %q: unknown base theme %q", name, baseName)
+		}
+		return p.over(base), nil
+	}
+	if p, ok := builtin[name]; ok {
+		return p, nil
+	}
+	return Palette{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(Names(custom), ", "))
+}
+
+// lookup finds a custom theme by name regardless of case, as the config
+// file may spell it differently from --theme.
+func lookup(custom map[string]Palette, name string) (Palette, bool) {
+	for n, p := range custom {
+		if strings.EqualFold(n, name) {
+			return p, true
+		}
+	}
+	return Palette{}, false
+}
+
+// IsMonochrome reports whether the palette defines no colors at all.
+func (p Palette) IsMonochrome() bool {
+	p.Base = ""
+	return p == Palette{}
+}
+
+func (p Palette) over(base Palette) Palette {
+	pick := func(v, fallback string) string {
+		if v != "" {
+			return v
+		}
+		return fallback
+	}
+	return Palette{
+		Primary:     pick(p.Primary, base.Primary),
+		Secondary:   pick(p.Secondary, base.Secondary),
+		Accent:      pick(p.Accent, base.Accent),
+		Text:        pick(p.Text, base.Text),
+		HighlightFg: pick(p.HighlightFg, base.HighlightFg),
+		HighlightBg: pick(p.HighlightBg, base.HighlightBg),
+		Error:       pick(p.Error, base.Error),
+	}
+}
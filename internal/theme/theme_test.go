@@ -0,0 +1,68 @@
+package theme
+
+import "testing"
+
+func TestResolveAuto(t *testing.T) {
+	p, err := Resolve("", nil, true)
+	if err != nil || p != builtin[Dark] {
+		t.Fatalf("dark background should resolve to dark theme")
+	}
+	p, err = Resolve("auto", nil, false)
+	if err != nil || p != builtin[Light] {
+		t.Fatalf("light background should resolve to light theme")
+	}
+}
+
+func TestResolveCustomInheritsBase(t *testing.T) {
+	custom := map[string]Palette{
+		"solar": {Base: "light", Primary: "#B58900"},
+	}
+	p, err := Resolve("solar", custom, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Primary != "#B58900" {
+		t.Fatalf("custom primary not applied: %q", p.Primary)
+	}
+	if p.Text != builtin[Light].Text {
+		t.Fatalf("text should come from base theme, got %q", p.Text)
+	}
+}
+
+func TestResolveCustomIgnoresCase(t *testing.T) {
+	custom := map[string]Palette{"Solarized": {Base: "Light", Primary: "#B58900"}}
+	p, err := Resolve("solarized", custom, true)
+	if err != nil || p.Primary != "#B58900" || p.Text != builtin[Light].Text {
+		t.Fatalf("Resolve = %+v, %v; want the custom theme over light", p, err)
+	}
+}
+
+func TestResolveCustomOverridesBuiltin(t *testing.T) {
+	p, err := Resolve("dark", map[string]Palette{"dark": {Primary: "#f00"}}, true)
+	if err != nil || p.Primary != "#f00" || p.Text != builtin[Dark].Text {
+		t.Fatalf("Resolve = %+v, %v; want #f00 over the built-in dark theme", p, err)
+	}
+	p, err = Resolve("light", map[string]Palette{"light": {Primary: "#f00"}}, true)
+	if err != nil || p.Text != builtin[Light].Text {
+		t.Fatalf("Resolve = %+v, %v; want a custom light over the built-in light theme", p, err)
+	}
+}
+
+func TestResolveUnknown(t *testing.T) {
+	if _, err := Resolve("nope", nil, true); err == nil {
+		t.Fatalf("expected error for unknown theme")
+	}
+	if _, err := Resolve("x", map[string]Palette{"x": {Base: "nope"}}, true); err == nil {
+		t.Fatalf("expected error for unknown base")
+	}
+}
+
+func TestMonochrome(t *testing.T) {
+	p, _ := Resolve(Monochrome, nil, true)
+	if !p.IsMonochrome() {
+		t.Fatalf("monochrome theme should have no colors")
+	}
+	if builtin[Dark].IsMonochrome() {
+		t.Fatalf("dark theme should not be monochrome")
+	}
+}
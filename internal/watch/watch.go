@@ -0,0 +1,203 @@
+// Package watch turns successive polls of a repository's open PRs into
+// events, such as a PR turning green or running into conflicts, and keeps
+// what it has seen on disk so a restarted watcher doesn't repeat them.
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+type Kind string
+
+const (
+	Opened      Kind = "opened"
+	Mergeable   Kind = "mergeable"
+	Conflicting Kind = "conflicting"
+	Green       Kind = "green"
+	Approved    Kind = "approved"
+	// Ready means the PR is approved, its checks pass and GitHub would merge
+	// it now. Repositories without branch protection report PRs that have
+	// neither as mergeable, so GitHub's word alone isn't enough.
+	Ready Kind = "ready"
+)
+
+type Event struct {
+	Kind Kind
+	Repo string
+	PR   gh.PR
+	// First is set on the poll that first saw the PR: the event describes
+	// the state it was found in rather than a change.
+	First bool
+}
+
+// Snapshot is what the watcher remembers about a PR between polls.
+type Snapshot struct {
+	Mergeable string `json:"mergeable,omitempty"`
+	Checks    string `json:"checks,omitempty"`
+	Review    string `json:"review,omitempty"`
+	Ready     bool   `json:"ready,omitempty"`
+}
+
+// State is the on-disk record for one repository.
+type State struct {
+	Repo   string           `json:"repo"`
+	PRs    map[int]Snapshot `json:"prs"`
+	Polled time.Time        `json:"polled"`
+
+	path string
+}
+
+// Path returns the state file for repo inside dir.
+func Path(dir, repo string) string {
+	return filepath.Join(dir, "watch", strings.ReplaceAll(repo, "/", "__")+".json")
+}
+
+// Load reads the state for repo from dir; a missing file is a watcher that
+// has seen nothing yet.
+func Load(dir, repo string) (*State, error) {
+	s := &State{Repo: repo, PRs: map[int]Snapshot{}, path: Path(dir, repo)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse watch state %s: %w", s.path, err)
+	}
+	if s.PRs == nil {
+		s.PRs = map[int]Snapshot{}
+	}
+	return s, nil
+}
+
+// Save writes the state atomically.
+func (s *State) Save() error {
+	// Which PRs a private repository has, and their state, is nobody
+	// else's business.
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Lock claims the repository for this process so two watchers don't act
+// on the same events. The returned func releases it.
+func (s *State) Lock() (func(), error) {
+	lock := s.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		pid, _ := os.ReadFile(lock)
+		return nil, fmt.Errorf("%s is already being watched (pid %s); remove %s if that process is gone",
+			s.Repo, strings.TrimSpace(string(pid)), lock)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+	_ = f.Close()
+	return func() { _ = os.Remove(lock) }, nil
+}
+
+// Observe compares prs, the repository's open PRs, with the previous poll
+// and returns what changed, in PR order. A PR seen for the first time
+// reports opened plus its current state, so the very first poll reports
+// every open PR. PRs that are no longer open are forgotten.
+func (s *State) Observe(prs []gh.PR, now time.Time) []Event {
+	var events []Event
+	next := make(map[int]Snapshot, len(prs))
+	for _, pr := range prs {
+		old, seen := s.PRs[pr.Number]
+		cur := snapshot(pr, old)
+		emit := func(k Kind) { events = append(events, Event{Kind: k, Repo: s.Repo, PR: pr, First: !seen}) }
+		if !seen {
+			emit(Opened)
+		}
+		if cur.Mergeable != old.Mergeable {
+			switch cur.Mergeable {
+			case "MERGEABLE":
+				emit(Mergeable)
+			case "CONFLICTING":
+				emit(Conflicting)
+			}
+		}
+		if cur.Checks == gh.ChecksPassing && old.Checks != gh.ChecksPassing {
+			emit(Green)
+		}
+		if cur.Review == "APPROVED" && old.Review != "APPROVED" {
+			emit(Approved)
+		}
+		if cur.Ready && !old.Ready {
+			emit(Ready)
+		}
+		next[pr.Number] = cur
+	}
+	s.PRs = next
+	s.Polled = now
+	return events
+}
+
+// snapshot records pr's state. GitHub computes mergeability lazily and
+// answers UNKNOWN meanwhile; the previous answer stands until it knows, so
+// a PR doesn't go mergeable twice.
+func snapshot(pr gh.PR, old Snapshot) Snapshot {
+	s := Snapshot{
+		Mergeable: pr.Mergeable,
+		Checks:    gh.CheckState(pr.StatusCheckRollup),
+		Review:    pr.ReviewDecision,
+	}
+	s.Ready = !pr.IsDraft && pr.MergeStateStatus == "CLEAN" &&
+		s.Checks == gh.ChecksPassing && s.Review == "APPROVED"
+	if s.Mergeable == "" || s.Mergeable == "UNKNOWN" {
+		s.Mergeable = old.Mergeable
+	}
+	if pr.MergeStateStatus == "" || pr.MergeStateStatus == "UNKNOWN" {
+		s.Ready = old.Ready
+	}
+	return s
+}
+
+// Retry makes the next Observe report ev again, e.g. when acting on it
+// failed or the watcher stopped before getting to it.
+func (s *State) Retry(ev Event) {
+	snap, ok := s.PRs[ev.PR.Number]
+	if !ok {
+		return
+	}
+	switch ev.Kind {
+	case Opened:
+		delete(s.PRs, ev.PR.Number)
+		return
+	case Mergeable, Conflicting:
+		snap.Mergeable = ""
+	case Green:
+		snap.Checks = ""
+	case Approved:
+		snap.Review = ""
+	case Ready:
+		snap.Ready = false
+	}
+	s.PRs[ev.PR.Number] = snap
+}
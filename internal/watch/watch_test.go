@@ -0,0 +1,110 @@
+package watch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"git-shippr/internal/gh"
+)
+
+var (
+	green   = []gh.Check{{Status: "COMPLETED", Conclusion: "SUCCESS"}}
+	running = []gh.Check{{Status: "IN_PROGRESS"}}
+)
+
+func kinds(events []Event) []Kind {
+	var out []Kind
+	for _, e := range events {
+		out = append(out, e.Kind)
+	}
+	return out
+}
+
+func TestObserve(t *testing.T) {
+	s, err := Load(t.TempDir(), "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	polls := []struct {
+		pr   gh.PR
+		want []Kind
+	}{
+		{gh.PR{Number: 1, Mergeable: "UNKNOWN", StatusCheckRollup: running}, []Kind{Opened}},
+		{gh.PR{Number: 1, Mergeable: "MERGEABLE", StatusCheckRollup: running}, []Kind{Mergeable}},
+		{gh.PR{Number: 1, Mergeable: "MERGEABLE", StatusCheckRollup: green}, []Kind{Green}},
+		// GitHub recomputing mergeability isn't news.
+		{gh.PR{Number: 1, Mergeable: "UNKNOWN", StatusCheckRollup: green}, nil},
+		{gh.PR{Number: 1, Mergeable: "MERGEABLE", StatusCheckRollup: green, ReviewDecision: "APPROVED", MergeStateStatus: "CLEAN"}, []Kind{Approved, Ready}},
+		// A new push reruns the checks and the base moves on.
+		{gh.PR{Number: 1, Mergeable: "CONFLICTING", StatusCheckRollup: running, ReviewDecision: "APPROVED", MergeStateStatus: "DIRTY"}, []Kind{Conflicting}},
+		{gh.PR{Number: 1, Mergeable: "MERGEABLE", StatusCheckRollup: green, ReviewDecision: "APPROVED", MergeStateStatus: "CLEAN"}, []Kind{Mergeable, Green, Ready}},
+	}
+	for i, p := range polls {
+		if got := kinds(s.Observe([]gh.PR{p.pr}, now)); !reflect.DeepEqual(got, p.want) {
+			t.Errorf("poll %d: events %v, want %v", i, got, p.want)
+		}
+	}
+	if s.Observe(nil, now); len(s.PRs) != 0 {
+		t.Errorf("closed PRs should be forgotten, have %v", s.PRs)
+	}
+}
+
+func TestStatePersists(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Load(dir, "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := gh.PR{Number: 7, Mergeable: "CONFLICTING"}
+	s.Observe([]gh.PR{pr}, time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Load(dir, "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Observe([]gh.PR{pr}, time.Now()); len(got) != 0 {
+		t.Errorf("a restarted watcher repeated %v", kinds(got))
+	}
+}
+
+func TestRetry(t *testing.T) {
+	s, err := Load(t.TempDir(), "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := gh.PR{Number: 3, Mergeable: "MERGEABLE", StatusCheckRollup: green, ReviewDecision: "APPROVED", MergeStateStatus: "CLEAN"}
+	s.Observe([]gh.PR{pr}, time.Now())
+	s.Retry(Event{Kind: Ready, PR: pr})
+	if got := kinds(s.Observe([]gh.PR{pr}, time.Now())); !reflect.DeepEqual(got, []Kind{Ready}) {
+		t.Errorf("after Retry: events %v, want [ready]", got)
+	}
+}
+
+func TestReadyNeedsChecksAndApproval(t *testing.T) {
+	s, err := Load(t.TempDir(), "acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without branch protection GitHub calls a PR with no checks and no
+	// reviews CLEAN.
+	pr := gh.PR{Number: 4, Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN"}
+	if got := kinds(s.Observe([]gh.PR{pr}, time.Now())); !reflect.DeepEqual(got, []Kind{Opened, Mergeable}) {
+		t.Errorf("unreviewed PR: events %v, want no ready", got)
+	}
+	pr.StatusCheckRollup, pr.ReviewDecision = green, "APPROVED"
+	events := s.Observe([]gh.PR{pr}, time.Now())
+	if got := kinds(events); !reflect.DeepEqual(got, []Kind{Green, Approved, Ready}) || events[2].First {
+		t.Errorf("events %v, want a ready that isn't first-seen", got)
+	}
+
+	first := gh.PR{Number: 5, Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN", StatusCheckRollup: green, ReviewDecision: "APPROVED"}
+	for _, ev := range s.Observe([]gh.PR{pr, first}, time.Now()) {
+		if !ev.First {
+			t.Errorf("%s on a newly seen PR should be marked First", ev.Kind)
+		}
+	}
+}